@@ -2,6 +2,8 @@ package aggregatedpool
 
 import (
 	"context"
+	stderr "errors"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,11 +11,12 @@ import (
 	"github.com/roadrunner-server/api/v2/payload"
 	"github.com/roadrunner-server/api/v2/pool"
 	"github.com/roadrunner-server/errors"
+	poolImpl "github.com/roadrunner-server/sdk/v2/pool"
 	"github.com/roadrunner-server/sdk/v2/utils"
 	"github.com/temporalio/roadrunner-temporal/internal"
+	"github.com/temporalio/roadrunner-temporal/internal/logger"
 	commonpb "go.temporal.io/api/common/v1"
 	tActivity "go.temporal.io/sdk/activity"
-	temporalClient "go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internalbindings"
 	"go.uber.org/zap"
@@ -28,34 +31,279 @@ const (
 type Activity struct {
 	codec   Codec
 	pool    pool.Pool
-	client  temporalClient.Client
 	log     *zap.Logger
 	dc      converter.DataConverter
 	seqID   uint64
 	running sync.Map
+	metrics *Metrics
+	events  *Events
 
 	graceTimout time.Duration
+	// added to the activity deadline before the worker is killed
+	deadlineGrace time.Duration
+	// the pool execution can be bounded by the activity deadline
+	boundedExec bool
+	// auto-heartbeat intervals by the activity type, zero means half of the heartbeat timeout
+	heartbeats map[string]time.Duration
+	// heartbeat throttling by the namespace and the task queue
+	throttles sync.Map
+	limiter   *ActivityLimiter
+	// free workers of the pool
+	slots *WorkerSlots
 }
 
-func NewActivityDefinition(ac Codec, p pool.Pool, log *zap.Logger, dc converter.DataConverter, client temporalClient.Client, gt time.Duration) *Activity {
+// RunningActivity describes an activity executed by the activity pool.
+type RunningActivity struct {
+	Name       string `json:"name"`
+	TaskQueue  string `json:"taskQueue"`
+	WorkflowID string `json:"workflowId"`
+	RunID      string `json:"runId"`
+	ActivityID string `json:"activityId"`
+	Attempt    int32  `json:"attempt"`
+	// StartedAt is the time the activity was sent to the pool.
+	StartedAt time.Time `json:"startedAt"`
+	// LastHeartbeat is the time of the last heartbeat recorded via RPC, zero if none.
+	LastHeartbeat time.Time `json:"lastHeartbeat,omitempty"`
+	// PID of the worker executing the activity. The pool does not report which worker executes the payload, so the
+	// PID is not known and left empty rather than guessed.
+	PID int64 `json:"pid,omitempty"`
+}
+
+type running struct {
+	ctx     context.Context
+	info    tActivity.Info
+	started time.Time
+	// unix nano
+	lastHeartbeat int64
+	// []interface{}, the last heartbeat details resent by the auto-heartbeat
+	details atomic.Value
+
+	// 1 while the activity is executed by the pool
+	executing uint32
+	// host-side heartbeats are enabled for the activity
+	autoHeartbeat bool
+
+	// heartbeats coalescing within the SDK throttle interval, see record
+	throttle time.Duration
+	mu       sync.Mutex
+	recorded time.Time
+	flush    *time.Timer
+	stopped  bool
+}
+
+func NewActivityDefinition(ac Codec, p pool.Pool, log *zap.Logger, dc converter.DataConverter, gt, dg time.Duration, hb map[string]time.Duration, lim *ActivityLimiter, ws *WorkerSlots, m *Metrics, ev *Events) *Activity {
+	bounded := boundedExec(p)
+	if !bounded {
+		log.Warn("the activities pool supervisor exec_ttl is not set, activities are not bounded by the deadline")
+	}
+
 	return &Activity{
-		log:         log,
-		client:      client,
-		codec:       ac,
-		pool:        p,
-		dc:          dc,
-		graceTimout: gt,
+		events:        ev,
+		metrics:       m,
+		log:           log,
+		codec:         ac,
+		pool:          p,
+		dc:            dc,
+		graceTimout:   gt,
+		deadlineGrace: dg,
+		boundedExec:   bounded,
+		heartbeats:    hb,
+		limiter:       lim,
+		slots:         ws,
 	}
 }
 
 func (a *Activity) GetActivityContext(taskToken []byte) (context.Context, error) {
 	const op = errors.Op("activity_pool_get_activity_context")
-	c, ok := a.running.Load(utils.AsString(taskToken))
+	r, ok := a.running.Load(utils.AsString(taskToken))
 	if !ok {
 		return nil, errors.E(op, errors.Str("heartbeat on non running activity"))
 	}
 
-	return c.(context.Context), nil
+	return r.(*running).ctx, nil
+}
+
+// RecordHeartbeat records heartbeat details for the running activity and returns its context.
+func (a *Activity) RecordHeartbeat(taskToken []byte, details ...interface{}) (context.Context, error) {
+	const op = errors.Op("activity_pool_record_heartbeat")
+	r, ok := a.running.Load(utils.AsString(taskToken))
+	if !ok {
+		return nil, errors.E(op, errors.Str("heartbeat on non running activity"))
+	}
+
+	ra := r.(*running)
+	ra.record(details)
+
+	return ra.ctx, nil
+}
+
+// Log writes the PHP activity log entry, the entry carries the activity and the parent workflow execution fields.
+func (a *Activity) Log(taskToken []byte, level, message string, context map[string]interface{}) error {
+	const op = errors.Op("activity_pool_log")
+	r, ok := a.running.Load(utils.AsString(taskToken))
+	if !ok {
+		return errors.E(op, errors.Str("log on non running activity"))
+	}
+
+	logger.Log(activityLogger(a.log, r.(*running).info), level, message, context)
+
+	return nil
+}
+
+// RunningActivities returns activities currently executed by the pool, ordered by the start time.
+func (a *Activity) RunningActivities() []*RunningActivity {
+	runs := a.runningSorted()
+
+	activities := make([]*RunningActivity, 0, len(runs))
+	for i := 0; i < len(runs); i++ {
+		r := runs[i]
+		ra := &RunningActivity{
+			Name:       r.info.ActivityType.Name,
+			TaskQueue:  r.info.TaskQueue,
+			WorkflowID: r.info.WorkflowExecution.ID,
+			RunID:      r.info.WorkflowExecution.RunID,
+			ActivityID: r.info.ActivityID,
+			Attempt:    r.info.Attempt,
+			StartedAt:  r.started,
+		}
+
+		if hb := atomic.LoadInt64(&r.lastHeartbeat); hb != 0 {
+			ra.LastHeartbeat = time.Unix(0, hb)
+		}
+
+		activities = append(activities, ra)
+	}
+
+	return activities
+}
+
+// runningSorted returns running activities ordered by the start time.
+func (a *Activity) runningSorted() []*running {
+	runs := make([]*running, 0, 10)
+	a.running.Range(func(_, value interface{}) bool {
+		runs = append(runs, value.(*running))
+		return true
+	})
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].started.Before(runs[j].started)
+	})
+
+	return runs
+}
+
+// activityLogger returns the child logger which carries the activity and the parent workflow execution fields.
+func activityLogger(log *zap.Logger, info tActivity.Info) *zap.Logger {
+	workflowType := ""
+	if info.WorkflowType != nil {
+		workflowType = info.WorkflowType.Name
+	}
+
+	return log.With(
+		zap.String("namespace", info.WorkflowNamespace),
+		zap.String("taskQueue", info.TaskQueue),
+		zap.String("workflowID", info.WorkflowExecution.ID),
+		zap.String("runID", info.WorkflowExecution.RunID),
+		zap.String("workflowType", workflowType),
+		zap.String("activityID", info.ActivityID),
+		zap.String("activityType", info.ActivityType.Name),
+		zap.Int32("attempt", info.Attempt),
+	)
+}
+
+func activityEvent(info tActivity.Info) *ActivityEvent {
+	return &ActivityEvent{
+		ActivityID:   info.ActivityID,
+		ActivityType: info.ActivityType.Name,
+		TaskQueue:    info.TaskQueue,
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		Attempt:      info.Attempt,
+	}
+}
+
+// exec sends the activity to the pool. The execution is bounded by the activity deadline plus the grace period, the
+// worker is killed and replaced by the pool when the deadline is exceeded. The activity cancellation is handled by the
+// PHP worker on the next heartbeat. The PHP worker of the auto-heartbeated activity may be blocked and never see the
+// cancellation, so it is killed when the activity is not completed within the grace period after the cancellation.
+func (a *Activity) exec(ctx context.Context, r *running, pld *payload.Payload) (*payload.Payload, error) {
+	deadline, ok := ctx.Deadline()
+	if !a.boundedExec || (!ok && !r.autoHeartbeat) {
+		return a.pool.Exec(pld)
+	}
+
+	execCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if ok {
+		var cancelDeadline context.CancelFunc
+		execCtx, cancelDeadline = context.WithDeadline(execCtx, deadline.Add(a.deadlineGrace))
+		defer cancelDeadline()
+	}
+
+	var canceled uint32
+	if r.autoHeartbeat {
+		go func() {
+			select {
+			case <-ctx.Done():
+				// the deadline is handled by the exec context
+				if !stderr.Is(ctx.Err(), context.Canceled) {
+					return
+				}
+			case <-execCtx.Done():
+				return
+			}
+
+			grace := time.NewTimer(a.deadlineGrace)
+			defer grace.Stop()
+
+			select {
+			case <-grace.C:
+				atomic.StoreUint32(&canceled, 1)
+				cancel()
+			case <-execCtx.Done():
+			}
+		}()
+	}
+
+	resp, err := a.pool.ExecWithTTL(execCtx, pld)
+	if err != nil && atomic.LoadUint32(&canceled) == 1 {
+		return nil, ctx.Err()
+	}
+
+	return resp, err
+}
+
+// execFree waits for a free worker and executes the activity, the wait and the execution are measured separately.
+func (a *Activity) execFree(ctx context.Context, r *running, pld *payload.Payload, am *activityMetrics) (*payload.Payload, error) {
+	// the activity is auto-heartbeated while waiting for a free worker too
+	atomic.StoreUint32(&r.executing, 1)
+	defer atomic.StoreUint32(&r.executing, 0)
+
+	release, wait, err := a.slots.acquire(ctx)
+	am.queueWait(wait)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	defer func() {
+		am.execution(time.Since(start))
+	}()
+
+	return a.exec(ctx, r, pld)
+}
+
+// boundedExec reports whether the pool execution can be bounded by the context. The supervised pool ignores the
+// context unless the supervisor exec TTL is set, the TTL bounds the execution together with the context then.
+func boundedExec(p pool.Pool) bool {
+	cfg, ok := p.GetConfig().(*poolImpl.Config)
+	if !ok {
+		return true
+	}
+
+	return cfg.Supervisor == nil || cfg.Supervisor.ExecTTL != 0
 }
 
 func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commonpb.Payloads, error) {
@@ -70,7 +318,6 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 	}
 
 	var info = tActivity.GetInfo(ctx)
-	a.running.Store(utils.AsString(info.TaskToken), ctx)
 	mh := tActivity.GetMetricsHandler(ctx)
 	// if the mh is not nil, record the RR metric
 	if mh != nil {
@@ -78,6 +325,11 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 		defer mh.Gauge(RrMetricName).Update(float64(a.pool.(pool.Queuer).QueueSize()))
 	}
 
+	am := a.metrics.activity(mh, info.ActivityType.Name, info.TaskQueue)
+	log := activityLogger(a.log, info)
+	log.Debug("activity execute")
+	ev := activityEvent(info)
+
 	var msg = &internal.Message{
 		ID: atomic.AddUint64(&a.seqID, 1),
 		Command: internal.InvokeActivity{
@@ -92,38 +344,100 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 		msg.Payloads.Payloads = append(msg.Payloads.Payloads, heartbeatDetails.Payloads...)
 	}
 
+	release, throttled, err := a.limiter.acquire(ctx, info.ActivityType.Name)
+	if throttled != 0 {
+		am.throttled(throttled)
+	}
+
+	if err != nil {
+		log.Warn("activity throttling", zap.Duration("throttled", throttled), zap.Error(err))
+		am.outcome(outcomeFailed)
+		return nil, errors.E(op, err)
+	}
+	defer release()
+
+	start := time.Now()
 	pld := &payload.Payload{}
-	err := a.codec.Encode(&internal.Context{TaskQueue: info.TaskQueue}, pld, msg)
+	err = a.codec.Encode(&internal.Context{TaskQueue: info.TaskQueue}, pld, msg)
 	if err != nil {
+		am.outcome(outcomeFailed)
 		return nil, err
 	}
+	serialization := time.Since(start)
 
-	result, err := a.pool.Exec(pld)
-	if err != nil {
-		a.running.Delete(utils.AsString(info.TaskToken))
-		return nil, errors.E(op, err)
+	start = time.Now()
+	a.events.Send(EventActivityStarted, ev)
+	r := &running{ctx: ctx, info: info, started: start, throttle: a.heartbeatThrottleInterval(info)}
+	// previous attempt details are kept until the PHP worker records new ones
+	if len(heartbeatDetails.Payloads) != 0 {
+		r.details.Store([]interface{}{heartbeatDetails})
+	} else {
+		r.details.Store([]interface{}(nil))
 	}
 
+	a.running.Store(utils.AsString(info.TaskToken), r)
+	stopHeartbeat := a.autoHeartbeat(ctx, r, log)
+	result, err := a.execFree(ctx, r, pld, am)
+	stopHeartbeat()
+	r.stop()
 	a.running.Delete(utils.AsString(info.TaskToken))
 
+	if err != nil {
+		switch {
+		case stderr.Is(err, context.Canceled):
+			log.Warn("activity canceled", zap.Duration("grace", a.deadlineGrace))
+			am.outcome(outcomeFailed)
+			ev.Error = err.Error()
+			a.events.Send(EventActivityFailed, ev)
+			// reported as canceled by the SDK
+			return nil, err
+		case errors.Is(errors.ExecTTL, err):
+			log.Error("activity deadline exceeded, the worker was killed", zap.Duration("grace", a.deadlineGrace))
+			am.deadlineExceeded()
+		}
+
+		log.Error("activity execution", zap.Error(err))
+		am.outcome(outcomeFailed)
+		ev.Error = err.Error()
+		a.events.Send(EventActivityFailed, ev)
+		return nil, errors.E(op, err)
+	}
+
+	start = time.Now()
 	out := make([]*internal.Message, 0, 2)
 	err = a.codec.Decode(result, &out)
+	am.serialization(serialization + time.Since(start))
 	if err != nil {
+		am.outcome(outcomeFailed)
+		ev.Error = err.Error()
+		a.events.Send(EventActivityFailed, ev)
 		return nil, err
 	}
 
 	if len(out) != 1 {
+		am.outcome(outcomeFailed)
+		ev.Error = "invalid activity worker response"
+		a.events.Send(EventActivityFailed, ev)
 		return nil, errors.E(op, errors.Str("invalid activity worker response"))
 	}
 
 	retPld := out[0]
 	if retPld.Failure != nil {
 		if retPld.Failure.Message == doNotCompleteOnReturn {
+			am.outcome(outcomeResultPending)
+			ev.ResultPending = true
+			a.events.Send(EventActivityFinished, ev)
 			return nil, tActivity.ErrResultPending
 		}
 
+		log.Debug("activity failed", zap.String("message", retPld.Failure.Message))
+		am.outcome(outcomeFailed)
+		ev.Error = retPld.Failure.Message
+		a.events.Send(EventActivityFailed, ev)
 		return nil, internalbindings.ConvertFailureToError(retPld.Failure, a.dc)
 	}
 
+	am.outcome(outcomeSucceed)
+	a.events.Send(EventActivityFinished, ev)
 	return retPld.Payloads, nil
 }
@@ -1,6 +1,7 @@
 package canceller
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -34,3 +35,18 @@ func (c *Canceller) Cancel(ids ...uint64) error {
 
 	return nil
 }
+
+// IDs returns sorted IDs of the commands which can still be canceled.
+func (c *Canceller) IDs() []uint64 {
+	ids := make([]uint64, 0, 2)
+	c.ids.Range(func(key, _ interface{}) bool {
+		ids = append(ids, key.(uint64))
+		return true
+	})
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids
+}
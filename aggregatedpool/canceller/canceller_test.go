@@ -31,3 +31,16 @@ func Test_CancellerListenerDiscarded(t *testing.T) {
 	c.Discard(1)
 	assert.NoError(t, c.Cancel(1))
 }
+
+func Test_CancellerIDs(t *testing.T) {
+	c := &Canceller{}
+	c.Register(3, func() error { return nil })
+	c.Register(1, func() error { return nil })
+	c.Register(2, func() error { return nil })
+
+	assert.Equal(t, []uint64{1, 2, 3}, c.IDs())
+
+	c.Discard(2)
+	assert.NoError(t, c.Cancel(3))
+	assert.Equal(t, []uint64{1}, c.IDs())
+}
@@ -0,0 +1,103 @@
+package aggregatedpool
+
+import (
+	"math"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/sdk/v2/utils"
+	tActivity "go.temporal.io/sdk/activity"
+	temporalClient "go.temporal.io/sdk/client"
+)
+
+const (
+	CustomMetricCounter string = "counter"
+	CustomMetricGauge   string = "gauge"
+	CustomMetricTimer   string = "timer"
+)
+
+// CustomMetric emitted by the PHP code via the Temporal metrics handler.
+type CustomMetric struct {
+	// Type is one of: counter, gauge, timer
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Value is the counter increment (an integer), the gauge value or the timer duration in milliseconds
+	Value float64           `json:"value"`
+	Tags  map[string]string `json:"tags"`
+}
+
+// RecordMetrics emits metrics via the metrics handler of the running activity, so they are tagged with the activity
+// namespace, task queue and type.
+func (a *Activity) RecordMetrics(taskToken []byte, metrics []*CustomMetric) error {
+	const op = errors.Op("activity_pool_record_metrics")
+	r, ok := a.running.Load(utils.AsString(taskToken))
+	if !ok {
+		return errors.E(op, errors.Str("metrics on non running activity"))
+	}
+
+	err := emitMetrics(tActivity.GetMetricsHandler(r.(*running).ctx), metrics)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// RecordMetrics emits metrics via the metrics handler of the cached workflow execution. The handler is replay-aware,
+// metrics recorded while the workflow is replaying are dropped.
+func (wp *Workflow) RecordMetrics(runID string, metrics []*CustomMetric) error {
+	const op = errors.Op("workflow_record_metrics")
+	w, ok := wp.cache.Load(runID)
+	if !ok {
+		return errors.E(op, errors.Errorf("no cached workflow execution with the runID: %s", runID))
+	}
+
+	err := emitMetrics(w.(*CachedWorkflow).mh, metrics)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// emitMetrics validates all metrics first, so the batch is either emitted completely or not at all.
+func emitMetrics(mh temporalClient.MetricsHandler, metrics []*CustomMetric) error {
+	for i := 0; i < len(metrics); i++ {
+		if metrics[i] == nil || metrics[i].Name == "" {
+			return errors.Str("metric name should not be empty")
+		}
+
+		switch metrics[i].Type {
+		case CustomMetricCounter, CustomMetricGauge, CustomMetricTimer:
+		default:
+			return errors.Errorf("unknown metric type: %s, metric: %s", metrics[i].Type, metrics[i].Name)
+		}
+
+		// counters are incremented by integers, fractions would be truncated
+		if metrics[i].Type == CustomMetricCounter && (math.Trunc(metrics[i].Value) != metrics[i].Value || math.Abs(metrics[i].Value) > math.MaxInt64) {
+			return errors.Errorf("counter value should be an integer, got: %v, metric: %s", metrics[i].Value, metrics[i].Name)
+		}
+	}
+
+	if mh == nil {
+		return nil
+	}
+
+	for i := 0; i < len(metrics); i++ {
+		h := mh
+		if len(metrics[i].Tags) > 0 {
+			h = mh.WithTags(metrics[i].Tags)
+		}
+
+		switch metrics[i].Type {
+		case CustomMetricCounter:
+			h.Counter(metrics[i].Name).Inc(int64(metrics[i].Value))
+		case CustomMetricGauge:
+			h.Gauge(metrics[i].Name).Update(metrics[i].Value)
+		case CustomMetricTimer:
+			h.Timer(metrics[i].Name).Record(time.Duration(metrics[i].Value * float64(time.Millisecond)))
+		}
+	}
+
+	return nil
+}
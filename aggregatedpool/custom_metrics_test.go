@@ -0,0 +1,30 @@
+package aggregatedpool
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally/v4"
+	sdktally "go.temporal.io/sdk/contrib/tally"
+)
+
+func Test_EmitMetricsCounter(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	mh := sdktally.NewMetricsHandler(scope)
+
+	require.NoError(t, emitMetrics(mh, []*CustomMetric{{Type: CustomMetricCounter, Name: "counter", Value: 2}}))
+	assert.Equal(t, int64(2), scope.Snapshot().Counters()["counter+"].Value())
+
+	for _, v := range []float64{0.5, math.NaN(), math.Inf(1)} {
+		// the batch is not emitted
+		err := emitMetrics(mh, []*CustomMetric{
+			{Type: CustomMetricCounter, Name: "counter", Value: 1},
+			{Type: CustomMetricCounter, Name: "counter", Value: v},
+		})
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, int64(2), scope.Snapshot().Counters()["counter+"].Value())
+}
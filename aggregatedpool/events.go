@@ -0,0 +1,118 @@
+package aggregatedpool
+
+import (
+	"github.com/goccy/go-json"
+	"github.com/roadrunner-server/api/v2/event_bus"
+	"github.com/roadrunner-server/sdk/v2/events"
+	"go.uber.org/zap"
+)
+
+// EventType of the temporal lifecycle events sent to the RoadRunner event bus. Subscribers match events by the
+// <plugin>.<type> pattern, e.g.: temporal.EventWorkflowFailed.
+type EventType uint32
+
+const (
+	// EventWorkflowStarted triggered when the new workflow execution is started (not during replay).
+	EventWorkflowStarted EventType = iota
+	// EventWorkflowCompleted triggered when the workflow completes or continues as new.
+	EventWorkflowCompleted
+	// EventWorkflowFailed triggered when the workflow completes with the failure.
+	EventWorkflowFailed
+	// EventActivityStarted triggered when the activity is sent to the worker.
+	EventActivityStarted
+	// EventActivityFinished triggered when the activity completes successfully or the result is pending.
+	EventActivityFinished
+	// EventActivityFailed triggered when the activity or the worker fails.
+	EventActivityFailed
+	// EventPoolReset triggered when the workflow and activity pools are reset.
+	EventPoolReset
+	// EventConnectionLost triggered when the temporal server becomes unreachable.
+	EventConnectionLost
+	// EventConnectionRestored triggered when the temporal server becomes reachable again.
+	EventConnectionRestored
+)
+
+func (et EventType) String() string {
+	switch et {
+	case EventWorkflowStarted:
+		return "EventWorkflowStarted"
+	case EventWorkflowCompleted:
+		return "EventWorkflowCompleted"
+	case EventWorkflowFailed:
+		return "EventWorkflowFailed"
+	case EventActivityStarted:
+		return "EventActivityStarted"
+	case EventActivityFinished:
+		return "EventActivityFinished"
+	case EventActivityFailed:
+		return "EventActivityFailed"
+	case EventPoolReset:
+		return "EventPoolReset"
+	case EventConnectionLost:
+		return "EventConnectionLost"
+	case EventConnectionRestored:
+		return "EventConnectionRestored"
+	default:
+		return "UnknownEventType"
+	}
+}
+
+// WorkflowEvent is the message of the workflow events (JSON encoded).
+type WorkflowEvent struct {
+	WorkflowID     string `json:"workflowId"`
+	RunID          string `json:"runId"`
+	WorkflowType   string `json:"workflowType"`
+	TaskQueue      string `json:"taskQueue"`
+	ContinuedAsNew bool   `json:"continuedAsNew,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// ActivityEvent is the message of the activity events (JSON encoded).
+type ActivityEvent struct {
+	ActivityID    string `json:"activityId"`
+	ActivityType  string `json:"activityType"`
+	TaskQueue     string `json:"taskQueue"`
+	WorkflowID    string `json:"workflowId"`
+	RunID         string `json:"runId"`
+	Attempt       int32  `json:"attempt"`
+	ResultPending bool   `json:"resultPending,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Events sends the temporal lifecycle events to the RoadRunner event bus. Nil Events is a no-op.
+type Events struct {
+	bus    event_bus.EventBus
+	plugin string
+	log    *zap.Logger
+}
+
+func NewEvents(bus event_bus.EventBus, plugin string, log *zap.Logger) *Events {
+	return &Events{
+		bus:    bus,
+		plugin: plugin,
+		log:    log,
+	}
+}
+
+// Send encodes the message to JSON, strings are sent as is.
+func (e *Events) Send(t EventType, message interface{}) {
+	if e == nil || e.bus == nil {
+		return
+	}
+
+	var msg string
+	switch m := message.(type) {
+	case string:
+		msg = m
+	default:
+		data, err := json.Marshal(m)
+		if err != nil {
+			e.log.Error("event message encoding", zap.String("event", t.String()), zap.Error(err))
+			return
+		}
+
+		msg = string(data)
+	}
+
+	e.bus.Send(events.NewEvent(t, e.plugin, msg))
+}
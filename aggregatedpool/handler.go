@@ -1,6 +1,7 @@
 package aggregatedpool
 
 import (
+	"context"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -9,16 +10,31 @@ import (
 	"github.com/roadrunner-server/api/v2/pool"
 	"github.com/roadrunner-server/errors"
 	"github.com/temporalio/roadrunner-temporal/internal"
+	"github.com/temporalio/roadrunner-temporal/internal/logger"
 	commonpb "go.temporal.io/api/common/v1"
 	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 	"go.uber.org/zap"
 )
 
 const (
 	completed string = "completed"
+
+	// reserved queries, handled by the plugin without calling the PHP worker
+	pendingCommandsQuery string = "__rr_pending_commands"
+	childWorkflowsQuery  string = "__rr_child_workflows"
+	queuedMessagesQuery  string = "__rr_queued_messages"
+	workerPIDQuery       string = "__rr_worker_pid"
 )
 
+// QueuedMessage describes a message waiting in the workflow message queue.
+type QueuedMessage struct {
+	ID      uint64 `json:"id"`
+	Command string `json:"command,omitempty"`
+	Failure bool   `json:"failure,omitempty"`
+}
+
 // execution context.
 func (wp *Workflow) getContext() *internal.Context {
 	return &internal.Context{
@@ -39,6 +55,12 @@ func (wp *Workflow) handleCancel() {
 
 // schedule the signal processing
 func (wp *Workflow) handleSignal(name string, input *commonpb.Payloads, header *commonpb.Header) error {
+	// session creation response sent by the session worker
+	if s, ok := wp.sessions[name]; ok && s.state == sessionStateCreating {
+		wp.handleSessionSignal(s, input)
+		return nil
+	}
+
 	wp.mq.PushCommand(
 		internal.InvokeSignal{
 			RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID,
@@ -54,6 +76,16 @@ func (wp *Workflow) handleSignal(name string, input *commonpb.Payloads, header *
 // Handle query in blocking mode.
 func (wp *Workflow) handleQuery(queryType string, queryArgs *commonpb.Payloads, header *commonpb.Header) (*commonpb.Payloads, error) {
 	const op = errors.Op("workflow_process_handle_query")
+
+	if result, ok := wp.builtinQuery(queryType); ok {
+		p, err := wp.env.GetDataConverter().ToPayloads(result)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+
+		return p, nil
+	}
+
 	result, err := wp.runCommand(internal.InvokeQuery{
 		RunID: wp.runID,
 		Name:  queryType,
@@ -70,13 +102,68 @@ func (wp *Workflow) handleQuery(queryType string, queryArgs *commonpb.Payloads,
 	return result.Payloads, nil
 }
 
+// builtinQuery returns the plugin-side state of the workflow for the reserved query types.
+func (wp *Workflow) builtinQuery(queryType string) (interface{}, bool) {
+	switch queryType {
+	case pendingCommandsQuery:
+		return wp.canceller.IDs(), true
+
+	case childWorkflowsQuery:
+		return wp.ids.Executions(), true
+
+	case queuedMessagesQuery:
+		msgs := wp.mq.Messages()
+		queued := make([]QueuedMessage, 0, len(msgs))
+		for i := 0; i < len(msgs); i++ {
+			qm := QueuedMessage{ID: msgs[i].ID, Failure: msgs[i].Failure != nil}
+			if msgs[i].IsCommand() {
+				qm.Command, _ = internal.CommandName(msgs[i].Command)
+			}
+
+			queued = append(queued, qm)
+		}
+
+		return queued, true
+
+	case workerPIDQuery:
+		// the workflow pool always consists of a single worker
+		workers := wp.pool.Workers()
+		if len(workers) == 0 {
+			return int64(0), true
+		}
+
+		return workers[0].Pid(), true
+
+	default:
+		return nil, false
+	}
+}
+
 // Workflow incoming command
 func (wp *Workflow) handleMessage(msg *internal.Message) error {
 	const op = errors.Op("handleMessage")
 
+	if name, err := internal.CommandName(msg.Command); err == nil {
+		wp.wm.command(name)
+	}
+
 	switch command := msg.Command.(type) {
 	case *internal.ExecuteActivity:
 		params := command.ActivityParams(wp.env, msg.Payloads)
+		if command.SessionID != "" {
+			taskQueue, err := wp.sessionTaskQueue(msg.ID, command.SessionID)
+			if err != nil {
+				errR := wp.respondError(msg.ID, err)
+				if errR != nil {
+					return errors.E(op, errR)
+				}
+
+				return nil
+			}
+
+			params.TaskQueueName = taskQueue
+		}
+
 		activityID := wp.env.ExecuteActivity(params, wp.createCallback(msg.ID))
 
 		wp.canceller.Register(msg.ID, func() error {
@@ -168,10 +255,13 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 
 		if msg.Failure == nil {
 			wp.env.Complete(msg.Payloads, nil)
+			wp.sendEvent(EventWorkflowCompleted, wp.workflowEvent(nil))
 			return nil
 		}
 
-		wp.env.Complete(nil, bindings.ConvertFailureToError(msg.Failure, wp.env.GetDataConverter()))
+		err := bindings.ConvertFailureToError(msg.Failure, wp.env.GetDataConverter())
+		wp.env.Complete(nil, err)
+		wp.sendEvent(EventWorkflowFailed, wp.workflowEvent(err))
 
 	case *internal.ContinueAsNew:
 		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
@@ -187,6 +277,10 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			WorkflowTaskTimeout:      command.Options.WorkflowTaskTimeout,
 		})
 
+		ev := wp.workflowEvent(nil)
+		ev.ContinuedAsNew = true
+		wp.sendEvent(EventWorkflowCompleted, ev)
+
 	case *internal.SignalExternalWorkflow:
 		wp.env.SignalExternalWorkflow(
 			command.Namespace,
@@ -203,6 +297,27 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 	case *internal.CancelExternalWorkflow:
 		wp.env.RequestCancelExternalWorkflow(command.Namespace, command.WorkflowID, command.RunID, wp.createCallback(msg.ID))
 
+	case *internal.CreateSession:
+		err := wp.createSession(msg.ID, command)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.CompleteSession:
+		err := wp.completeSession(msg.ID, command)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.Log:
+		if !wp.env.IsReplaying() {
+			logger.Log(wp.log, command.Level, command.Message, command.Context)
+		}
+
+		// the worker does not wait for the result, the response is sent with the next flush
+		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+		wp.mq.PushResponse(msg.ID, result)
+
 	case *internal.Cancel:
 		err := wp.canceller.Cancel(command.CommandIDs...)
 		if err != nil {
@@ -219,7 +334,12 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 
 	case *internal.Panic:
 		// do not wrap error to pass it directly to Temporal
-		return bindings.ConvertFailureToError(msg.Failure, wp.env.GetDataConverter())
+		err := bindings.ConvertFailureToError(msg.Failure, wp.env.GetDataConverter())
+		if err == nil {
+			err = errors.Str(command.Message)
+		}
+
+		return &panicError{err: err}
 
 	default:
 		return errors.E(op, errors.Str("undefined command"))
@@ -317,29 +437,12 @@ func (wp *Workflow) flushQueue() error {
 		defer wp.mh.Gauge(RrWorkflowsMetricName).Update(float64(wp.pool.(pool.Queuer).QueueSize()))
 	}
 
-	// todo(rustatian) to sync.Pool
-	pld := &payload.Payload{}
-	err := wp.codec.Encode(wp.getContext(), pld, wp.mq.Messages()...)
-	if err != nil {
-		return err
-	}
-
-	resp, err := wp.pool.Exec(pld)
-	if err != nil {
-		return err
-	}
-
-	msgs := make([]*internal.Message, 0, 2)
-	err = wp.codec.Decode(resp, &msgs)
-	if err != nil {
-		return err
-	}
-	wp.mq.Flush()
-
+	msgs, err := wp.exchange(wp.workflowTaskTimeout(), wp.mq.Messages()...)
 	if err != nil {
 		return errors.E(op, err)
 	}
 
+	wp.mq.Flush()
 	wp.pipeline = append(wp.pipeline, msgs...)
 
 	return nil
@@ -355,26 +458,66 @@ func (wp *Workflow) runCommand(cmd interface{}, payloads *commonpb.Payloads, hea
 		defer wp.mh.Gauge(RrMetricName).Update(float64(wp.pool.(pool.Queuer).QueueSize()))
 	}
 
-	pld := &payload.Payload{}
-	err := wp.codec.Encode(wp.getContext(), pld, msg)
+	msgs, err := wp.exchange(wp.queryTimeout, msg)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := wp.pool.Exec(pld)
+	if len(msgs) != 1 {
+		return nil, errors.E(op, errors.Str("unexpected pool response"))
+	}
+
+	return msgs[0], nil
+}
+
+// exchange sends the frame to the workflow worker and returns received messages, zero timeout means no deadline.
+func (wp *Workflow) exchange(timeout time.Duration, msgs ...*internal.Message) ([]*internal.Message, error) {
+	start := time.Now()
+	// todo(rustatian) to sync.Pool
+	pld := &payload.Payload{}
+	err := wp.codec.Encode(wp.getContext(), pld, msgs...)
 	if err != nil {
 		return nil, err
 	}
+	wp.wm.encode(time.Since(start))
+
+	start = time.Now()
+	resp, err := wp.exec(timeout, pld)
+	if err != nil {
+		return nil, poolError(err)
+	}
+	wp.wm.exec(time.Since(start))
 
-	msgs := make([]*internal.Message, 0, 2)
-	err = wp.codec.Decode(resp, &msgs)
+	start = time.Now()
+	received := make([]*internal.Message, 0, 2)
+	err = wp.codec.Decode(resp, &received)
 	if err != nil {
 		return nil, err
 	}
+	wp.wm.decode(time.Since(start))
+	wp.wm.frame(len(msgs), len(received))
 
-	if len(msgs) != 1 {
-		return nil, errors.E(op, errors.Str("unexpected pool response"))
+	return received, nil
+}
+
+// exec sends the payload to the workflow worker. The worker which does not respond within the timeout is killed and
+// replaced by the pool. The new worker knows nothing about the running workflows, so the sticky cache is purged and
+// workflows are replayed from the history.
+func (wp *Workflow) exec(timeout time.Duration, pld *payload.Payload) (*payload.Payload, error) {
+	if timeout <= 0 {
+		return wp.pool.Exec(pld)
 	}
 
-	return msgs[0], nil
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := wp.pool.ExecWithTTL(ctx, pld)
+	if err != nil && errors.Is(errors.ExecTTL, err) {
+		wp.log.Error("workflow worker did not respond in time and was killed, purging the sticky workflow cache", zap.Duration("timeout", timeout), zap.Error(err))
+		wp.wm.workerTimeout()
+		// evicted workflows wait for the current workflow task to complete
+		go worker.PurgeStickyWorkflowCache()
+	}
+
+	return resp, err
 }
@@ -0,0 +1,120 @@
+package aggregatedpool
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/roadrunner-server/api/v2/payload"
+	"github.com/roadrunner-server/api/v2/pool"
+	"github.com/roadrunner-server/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/aggregatedpool/queue"
+	"github.com/temporalio/roadrunner-temporal/internal"
+	"github.com/temporalio/roadrunner-temporal/internal/codec/proto"
+	"go.temporal.io/sdk/converter"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// hungPool never responds, the call returns when the context is done.
+type hungPool struct {
+	pool.Pool
+}
+
+func (p *hungPool) ExecWithTTL(ctx context.Context, _ *payload.Payload) (*payload.Payload, error) {
+	<-ctx.Done()
+	return nil, errors.E(errors.ExecTTL, ctx.Err())
+}
+
+type testEnv struct {
+	bindings.WorkflowEnvironment
+	info *workflow.Info
+}
+
+func (e *testEnv) WorkflowInfo() *workflow.Info {
+	return e.info
+}
+
+func (e *testEnv) Now() time.Time {
+	return time.Now()
+}
+
+func (e *testEnv) IsReplaying() bool {
+	return false
+}
+
+func Test_QueryTimeout(t *testing.T) {
+	codec := proto.NewCodec(zap.NewNop(), converter.GetDefaultDataConverter(), nil)
+	seqID := func() uint64 { return 1 }
+
+	wp := NewWorkflowDefinition(codec, converter.GetDefaultDataConverter(), &hungPool{}, zap.NewNop(), seqID, time.Second, time.Millisecond*100, 0, NewMetrics(true), nil, false)
+	wp.env = &testEnv{info: &workflow.Info{
+		WorkflowType:  workflow.Type{Name: "wf"},
+		TaskQueueName: "default",
+	}}
+	wp.mq = queue.NewMessageQueue(seqID)
+	wp.wm = wp.metrics.workflow(nil, "wf", "default", wp.env.IsReplaying)
+
+	start := time.Now()
+	_, err := wp.handleQuery("hung", nil, nil)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	// the hung worker is killed
+	assert.Equal(t, float64(1), testutil.ToFloat64(wp.metrics.workflowTimeouts.WithLabelValues("wf", "default")))
+}
+
+// the hung local activity worker is killed after the deadline and the grace period
+func Test_LocalActivityDeadline(t *testing.T) {
+	wp := &Workflow{laPool: &hungPool{}, laBounded: true, laGrace: time.Millisecond * 100}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	start := time.Now()
+	_, err := wp.execLocalActivity(ctx, &payload.Payload{})
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*150)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// the hung workflow worker is killed after the deadlock detection timeout and the workflow task fails
+func Test_WorkflowTaskTimeout(t *testing.T) {
+	codec := proto.NewCodec(zap.NewNop(), converter.GetDefaultDataConverter(), nil)
+	seqID := func() uint64 { return 1 }
+
+	wp := NewWorkflowDefinition(codec, converter.GetDefaultDataConverter(), &hungPool{}, zap.NewNop(), seqID, time.Second, time.Second, 0, NewMetrics(true), nil, false)
+	wp.env = &testEnv{info: &workflow.Info{
+		WorkflowType:  workflow.Type{Name: "wf"},
+		TaskQueueName: "default",
+	}}
+	wp.mq = queue.NewMessageQueue(seqID)
+	wp.wm = wp.metrics.workflow(nil, "wf", "default", wp.env.IsReplaying)
+	wp.startSent = true
+	wp.mq.PushCommand(internal.InvokeSignal{Name: "signal"}, nil, nil)
+
+	start := time.Now()
+	// transient failures panic, so the workflow task fails and is retried by the server
+	assert.Panics(t, func() { wp.OnWorkflowTaskStarted(time.Millisecond * 100) })
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the hung worker is killed
+	assert.Equal(t, float64(1), testutil.ToFloat64(wp.metrics.workflowTimeouts.WithLabelValues("wf", "default")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(wp.metrics.workflowFailures.WithLabelValues("wf", "default", failureReasonTransient, failureOutcomeRetry)))
+}
+
+func Test_WorkflowTaskTimeoutConfigured(t *testing.T) {
+	wp := &Workflow{deadlockTimeout: time.Second}
+	assert.Equal(t, time.Second, wp.workflowTaskTimeout())
+
+	wp.taskTimeout = time.Minute
+	assert.Equal(t, time.Minute, wp.workflowTaskTimeout())
+
+	// the SDK debug mode
+	wp = &Workflow{deadlockTimeout: math.MaxInt64}
+	assert.Zero(t, wp.workflowTaskTimeout())
+}
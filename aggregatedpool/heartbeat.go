@@ -0,0 +1,151 @@
+package aggregatedpool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	tActivity "go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+)
+
+// the SDK heartbeat throttling defaults, see worker.Options
+const (
+	defaultHeartbeatThrottleInterval = time.Second * 30
+	maxHeartbeatThrottleInterval     = time.Second * 60
+)
+
+// heartbeatThrottle is the heartbeat throttling of the worker, zero values are the SDK defaults.
+type heartbeatThrottle struct {
+	defaultInterval time.Duration
+	maxInterval     time.Duration
+}
+
+// interval returns the SDK heartbeat throttle interval for the activity heartbeat timeout.
+func (t heartbeatThrottle) interval(heartbeatTimeout time.Duration) time.Duration {
+	var interval time.Duration
+	switch {
+	case heartbeatTimeout > 0:
+		interval = heartbeatTimeout * 4 / 5
+	case t.defaultInterval > 0:
+		interval = t.defaultInterval
+	default:
+		interval = defaultHeartbeatThrottleInterval
+	}
+
+	maxInterval := t.maxInterval
+	if maxInterval == 0 {
+		maxInterval = maxHeartbeatThrottleInterval
+	}
+
+	if interval > maxInterval {
+		return maxInterval
+	}
+
+	return interval
+}
+
+// heartbeatThrottleInterval returns the heartbeat throttle interval of the activity worker.
+func (a *Activity) heartbeatThrottleInterval(info tActivity.Info) time.Duration {
+	var t heartbeatThrottle
+	if v, ok := a.throttles.Load(taskQueueKey{namespace: info.WorkflowNamespace, taskQueue: info.TaskQueue}); ok {
+		t = v.(heartbeatThrottle)
+	}
+
+	return t.interval(info.HeartbeatTimeout)
+}
+
+// record records the heartbeat via the SDK at most once per the throttle interval. Details received in between are
+// recorded by the timer when the interval elapses, so the last details are never lost.
+func (r *running) record(details []interface{}) {
+	atomic.StoreInt64(&r.lastHeartbeat, time.Now().UnixNano())
+	r.details.Store(details)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the activity is completed or the details are recorded by the scheduled flush
+	if r.stopped || r.flush != nil {
+		return
+	}
+
+	if elapsed := time.Since(r.recorded); elapsed < r.throttle {
+		r.flush = time.AfterFunc(r.throttle-elapsed, r.flushDetails)
+		return
+	}
+
+	r.recorded = time.Now()
+	tActivity.RecordHeartbeat(r.ctx, details...)
+}
+
+func (r *running) flushDetails() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.flush == nil {
+		return
+	}
+
+	r.flush = nil
+	r.recorded = time.Now()
+	tActivity.RecordHeartbeat(r.ctx, r.details.Load().([]interface{})...)
+}
+
+// stop cancels the scheduled heartbeat, called when the activity execution is finished.
+func (r *running) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = true
+	if r.flush != nil {
+		r.flush.Stop()
+		r.flush = nil
+	}
+}
+
+// autoHeartbeat records heartbeats with the last PHP supplied details while the activity is executed by the pool.
+// Heartbeats are recorded only for the activity types listed in the auto-heartbeat configuration and having the
+// heartbeat timeout, they are coalesced with the PHP heartbeats. The SDK cancels the activity context when the
+// cancellation is requested, see exec. The returned function stops the heartbeats.
+func (a *Activity) autoHeartbeat(ctx context.Context, r *running, log *zap.Logger) func() {
+	interval, ok := a.heartbeats[r.info.ActivityType.Name]
+	if !ok || r.info.HeartbeatTimeout == 0 {
+		return func() {}
+	}
+
+	if interval == 0 {
+		interval = r.info.HeartbeatTimeout / 2
+	}
+
+	r.autoHeartbeat = true
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+
+	go func() {
+		defer close(doneCh)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if atomic.LoadUint32(&r.executing) == 0 {
+					continue
+				}
+
+				r.record(r.details.Load().([]interface{}))
+			case <-ctx.Done():
+				log.Debug("activity context is done, auto-heartbeat stopped", zap.Error(ctx.Err()))
+				return
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		<-doneCh
+	}
+}
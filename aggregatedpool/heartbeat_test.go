@@ -0,0 +1,81 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roadrunner-server/api/v2/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tActivity "go.temporal.io/sdk/activity"
+)
+
+// the blocked worker of the auto-heartbeated activity is killed after the grace period when the activity is canceled
+func Test_ExecCanceled(t *testing.T) {
+	a := &Activity{pool: &hungPool{}, boundedExec: true, deadlineGrace: time.Millisecond * 100}
+	r := &running{autoHeartbeat: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*50, cancel)
+
+	start := time.Now()
+	_, err := a.exec(ctx, r, &payload.Payload{})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*150)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func Test_HeartbeatThrottleInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		throttle heartbeatThrottle
+		timeout  time.Duration
+		expected time.Duration
+	}{
+		{"heartbeat timeout", heartbeatThrottle{}, time.Second * 10, time.Second * 8},
+		{"sdk default", heartbeatThrottle{}, 0, time.Second * 30},
+		{"sdk max", heartbeatThrottle{}, time.Minute * 5, time.Second * 60},
+		{"configured default", heartbeatThrottle{defaultInterval: time.Second * 5}, 0, time.Second * 5},
+		{"configured max", heartbeatThrottle{maxInterval: time.Second * 2}, time.Second * 10, time.Second * 2},
+		{"configured default over max", heartbeatThrottle{defaultInterval: time.Second * 5, maxInterval: time.Second}, 0, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.throttle.interval(tt.timeout))
+		})
+	}
+}
+
+// the worker options of the activity task queue are used
+func Test_ActivityHeartbeatThrottle(t *testing.T) {
+	a := &Activity{}
+	a.throttles.Store(taskQueueKey{namespace: "default", taskQueue: "q"}, heartbeatThrottle{defaultInterval: time.Second})
+
+	assert.Equal(t, time.Second, a.heartbeatThrottleInterval(tActivity.Info{WorkflowNamespace: "default", TaskQueue: "q"}))
+	assert.Equal(t, time.Second*30, a.heartbeatThrottleInterval(tActivity.Info{WorkflowNamespace: "other", TaskQueue: "q"}))
+}
+
+// heartbeats within the throttle interval are coalesced into the single scheduled flush with the last details
+func Test_HeartbeatCoalesced(t *testing.T) {
+	r := &running{throttle: time.Minute, recorded: time.Now()}
+
+	r.record([]interface{}{1})
+	r.mu.Lock()
+	flush := r.flush
+	r.mu.Unlock()
+	require.NotNil(t, flush)
+
+	r.record([]interface{}{2})
+	r.mu.Lock()
+	assert.Same(t, flush, r.flush)
+	r.mu.Unlock()
+	assert.Equal(t, []interface{}{2}, r.details.Load())
+
+	r.stop()
+	assert.Nil(t, r.flush)
+	// no flush is scheduled after the execution is finished
+	r.record([]interface{}{3})
+	assert.Nil(t, r.flush)
+}
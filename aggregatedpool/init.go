@@ -63,11 +63,19 @@ func GrabActivities(wi []*internal.WorkerInfo) map[string]*internal.ActivityInfo
 	return activitiesInfo
 }
 
-func InitWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo, log *zap.Logger, tc temporalClient.Client, graceTimeout time.Duration) ([]worker.Worker, error) {
+// InitWorkers creates workers, clients should contain the client for every worker info namespace. The empty worker
+// info namespace is the default namespace of clients.
+func InitWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo, log *zap.Logger, clients map[string]temporalClient.Client, defaultNamespace string, graceTimeout time.Duration) ([]worker.Worker, error) {
+	const op = errors.Op("init_workers")
 	workers := make([]worker.Worker, 0, 1)
 
 	for i := 0; i < len(wi); i++ {
-		log.Debug("worker info", zap.String("taskqueue", wi[i].TaskQueue), zap.Any("options", wi[i].Options))
+		log.Debug("worker info", zap.String("taskqueue", wi[i].TaskQueue), zap.String("namespace", wi[i].Namespace), zap.Any("options", wi[i].Options))
+
+		tc, ok := clients[wi[i].Namespace]
+		if !ok {
+			return nil, errors.E(op, errors.Errorf("no client for the namespace: %s, task queue: %s", wi[i].Namespace, wi[i].TaskQueue))
+		}
 
 		wi[i].Options.WorkerStopTimeout = graceTimeout
 
@@ -83,7 +91,23 @@ func InitWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo, lo
 			)
 		}
 
-		wrk := worker.New(tc, wi[i].TaskQueue, wi[i].Options)
+		// the panic policy is applied by the workflow definition, the SDK policy would fail the workflow on transient
+		// errors too
+		namespace := wi[i].Namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+
+		key := taskQueueKey{namespace: namespace, taskQueue: wi[i].TaskQueue}
+		wDef.panicPolicies.Store(key, wi[i].Options.WorkflowPanicPolicy)
+		actDef.throttles.Store(key, heartbeatThrottle{
+			defaultInterval: wi[i].Options.DefaultHeartbeatThrottleInterval,
+			maxInterval:     wi[i].Options.MaxHeartbeatThrottleInterval,
+		})
+		opts := wi[i].Options
+		opts.WorkflowPanicPolicy = worker.BlockWorkflow
+
+		wrk := worker.New(tc, wi[i].TaskQueue, opts)
 
 		for j := 0; j < len(wi[i].Workflows); j++ {
 			wrk.RegisterWorkflowWithOptions(wDef, workflow.RegisterOptions{
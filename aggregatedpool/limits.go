@@ -0,0 +1,147 @@
+package aggregatedpool
+
+import (
+	"context"
+	"time"
+
+	"github.com/roadrunner-server/api/v2/pool"
+	"github.com/roadrunner-server/errors"
+	poolImpl "github.com/roadrunner-server/sdk/v2/pool"
+	"golang.org/x/time/rate"
+)
+
+// ActivityLimiter caps the concurrency and the rate of activity executions by the activity type on this instance.
+type ActivityLimiter struct {
+	limits map[string]*activityLimit
+}
+
+type activityLimit struct {
+	// nil if the concurrency is not limited
+	sem chan struct{}
+	// nil if the rate is not limited
+	rate *rate.Limiter
+}
+
+func NewActivityLimiter() *ActivityLimiter {
+	return &ActivityLimiter{
+		limits: make(map[string]*activityLimit),
+	}
+}
+
+// SetLimit sets the limits of the activity type, zero values mean no limit. The burst is 1 by default. Should be
+// called before the activity definition is used.
+func (l *ActivityLimiter) SetLimit(activity string, maxConcurrent int, perSecond float64, burst int) {
+	al := &activityLimit{}
+
+	if maxConcurrent > 0 {
+		al.sem = make(chan struct{}, maxConcurrent)
+	}
+
+	if perSecond > 0 {
+		if burst <= 0 {
+			burst = 1
+		}
+
+		al.rate = rate.NewLimiter(rate.Limit(perSecond), burst)
+	}
+
+	l.limits[activity] = al
+}
+
+// acquire waits until the activity is allowed to execute, the returned function releases the concurrency slot. The
+// error is returned when the context is done while waiting.
+func (l *ActivityLimiter) acquire(ctx context.Context, activity string) (func(), time.Duration, error) {
+	if l == nil {
+		return func() {}, 0, nil
+	}
+
+	al, ok := l.limits[activity]
+	if !ok {
+		return func() {}, 0, nil
+	}
+
+	start := time.Now()
+
+	if al.sem != nil {
+		select {
+		case al.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, time.Since(start), ctx.Err()
+		}
+	}
+
+	release := func() {
+		if al.sem != nil {
+			<-al.sem
+		}
+	}
+
+	if al.rate != nil {
+		err := al.rate.Wait(ctx)
+		if err != nil {
+			release()
+			return nil, time.Since(start), err
+		}
+	}
+
+	return release, time.Since(start), nil
+}
+
+// WorkerSlots admits at most as many executions as the pool has workers, so the time spent waiting for a free worker is
+// measured apart from the execution. Activities and local activities executed by the same pool share the slots.
+type WorkerSlots struct {
+	sem chan struct{}
+	// the pool allocate timeout
+	timeout time.Duration
+}
+
+// NewWorkerSlots returns slots of the pool workers, nil (not limited) for the debug pool creating a worker per
+// execution.
+func NewWorkerSlots(p pool.Pool) *WorkerSlots {
+	cfg, ok := p.GetConfig().(*poolImpl.Config)
+	if !ok || cfg.Debug || cfg.NumWorkers == 0 {
+		return nil
+	}
+
+	return &WorkerSlots{
+		sem:     make(chan struct{}, cfg.NumWorkers),
+		timeout: cfg.AllocateTimeout,
+	}
+}
+
+// acquire waits for a free worker within the pool allocate timeout, the returned function releases the slot. The
+// error is returned when the context is done or the timeout is exceeded while waiting.
+func (s *WorkerSlots) acquire(ctx context.Context) (func(), time.Duration, error) {
+	const op = errors.Op("worker_slots_acquire")
+
+	if s == nil {
+		return func() {}, 0, nil
+	}
+
+	start := time.Now()
+	release := func() {
+		<-s.sem
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		return release, time.Since(start), nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if s.timeout > 0 {
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		return release, time.Since(start), nil
+	case <-ctx.Done():
+		return nil, time.Since(start), ctx.Err()
+	case <-timeout:
+		return nil, time.Since(start), errors.E(op, errors.NoFreeWorkers)
+	}
+}
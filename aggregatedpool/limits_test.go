@@ -0,0 +1,69 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roadrunner-server/api/v2/payload"
+	"github.com/roadrunner-server/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally/v4"
+	sdktally "go.temporal.io/sdk/contrib/tally"
+)
+
+// sleepPool responds after the delay.
+type sleepPool struct {
+	hungPool
+	delay time.Duration
+}
+
+func (p *sleepPool) Exec(_ *payload.Payload) (*payload.Payload, error) {
+	time.Sleep(p.delay)
+	return &payload.Payload{}, nil
+}
+
+func Test_WorkerSlots(t *testing.T) {
+	s := &WorkerSlots{sem: make(chan struct{}, 1), timeout: time.Millisecond * 50}
+
+	release, wait, err := s.acquire(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, wait, time.Millisecond*50)
+
+	_, wait, err = s.acquire(context.Background())
+	assert.True(t, errors.Is(errors.NoFreeWorkers, err))
+	assert.GreaterOrEqual(t, wait, time.Millisecond*50)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = s.acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	release()
+	release, _, err = s.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+}
+
+// the time waiting for a free worker is not included in the execution time
+func Test_ExecFreeMetrics(t *testing.T) {
+	slots := &WorkerSlots{sem: make(chan struct{}, 1), timeout: time.Second}
+	a := &Activity{pool: &sleepPool{delay: time.Millisecond * 50}, slots: slots}
+
+	busy, _, err := slots.acquire(context.Background())
+	require.NoError(t, err)
+	time.AfterFunc(time.Millisecond*100, busy)
+
+	scope := tally.NewTestScope("", nil)
+	am := NewMetrics(true).activity(sdktally.NewMetricsHandler(scope), "activity", "default")
+	_, err = a.execFree(context.Background(), &running{}, &payload.Payload{}, am)
+	require.NoError(t, err)
+
+	timers := scope.Snapshot().Timers()
+	require.Contains(t, timers, activityQueueWaitLatency+"+")
+	require.Contains(t, timers, activityExecutionLatency+"+")
+	assert.GreaterOrEqual(t, timers[activityQueueWaitLatency+"+"].Values()[0], time.Millisecond*100)
+	assert.GreaterOrEqual(t, timers[activityExecutionLatency+"+"].Values()[0], time.Millisecond*50)
+	assert.Less(t, timers[activityExecutionLatency+"+"].Values()[0], time.Millisecond*100)
+}
@@ -0,0 +1,326 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/uber-go/tally/v4"
+	temporalClient "go.temporal.io/sdk/client"
+	sdktally "go.temporal.io/sdk/contrib/tally"
+)
+
+const (
+	metricsNamespace string = "rr_temporal"
+
+	activityExecutionLatency     string = "rr_activity_execution_latency"
+	activitySerializationLatency string = "rr_activity_serialization_latency"
+	activityQueueWaitLatency     string = "rr_activity_queue_wait_latency"
+	activityThrottledLatency     string = "rr_activity_throttled_latency"
+	activitySucceed              string = "rr_activity_succeed"
+	activityFailed               string = "rr_activity_failed"
+	activityResultPending        string = "rr_activity_result_pending"
+	activityDeadlineExceeded     string = "rr_activity_deadline_exceeded"
+
+	workflowEncodeLatency    string = "rr_workflow_encode_latency"
+	workflowExecLatency      string = "rr_workflow_exec_latency"
+	workflowDecodeLatency    string = "rr_workflow_decode_latency"
+	workflowFrames           string = "rr_workflow_frames"
+	workflowMessagesSent     string = "rr_workflow_messages_sent"
+	workflowMessagesReceived string = "rr_workflow_messages_received"
+	workflowCommands         string = "rr_workflow_commands"
+	workflowTaskFailures     string = "rr_workflow_task_failures"
+	workflowWorkerTimeouts   string = "rr_workflow_worker_timeouts"
+
+	outcomeSucceed       string = "succeed"
+	outcomeFailed        string = "failed"
+	outcomeResultPending string = "result_pending"
+)
+
+// messages per frame buckets
+var messagesBuckets = tally.ValueBuckets{1, 2, 5, 10, 25, 50, 100, 250}
+
+// Metrics recorded by the plugin. Every value is recorded by the prometheus collectors exported via the RoadRunner
+// metrics plugin and reported to the Temporal metrics handler (tally scope) of the configured metrics driver. The rr
+// driver exports the tally scope via the RoadRunner metrics plugin too, so values are not reported to the handler then.
+type Metrics struct {
+	// report values to the Temporal metrics handler
+	handler bool
+
+	activityExecution     *prom.HistogramVec
+	activitySerialization *prom.HistogramVec
+	activityQueueWait     *prom.HistogramVec
+	activityThrottled     *prom.HistogramVec
+	activityOutcome       *prom.CounterVec
+	activityDeadline      *prom.CounterVec
+
+	workflowEncode   *prom.HistogramVec
+	workflowExec     *prom.HistogramVec
+	workflowDecode   *prom.HistogramVec
+	workflowMessages *prom.HistogramVec
+	workflowCommands *prom.CounterVec
+	workflowFailures *prom.CounterVec
+	workflowTimeouts *prom.CounterVec
+}
+
+func NewMetrics(handler bool) *Metrics {
+	activityLabels := []string{"activity_type", "task_queue"}
+	workflowLabels := []string{"workflow_type", "task_queue"}
+
+	return &Metrics{
+		handler: handler,
+		activityExecution: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "activity_execution_seconds",
+			Help:      "Activity execution time in the PHP worker",
+		}, activityLabels),
+		activitySerialization: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "activity_serialization_seconds",
+			Help:      "Activity payloads encoding and decoding time",
+		}, activityLabels),
+		activityQueueWait: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "activity_queue_wait_seconds",
+			Help:      "Time activities spent waiting for a free worker",
+		}, activityLabels),
+		activityThrottled: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "activity_throttled_seconds",
+			Help:      "Time activities spent waiting for the activity type concurrency or rate limit",
+		}, activityLabels),
+		activityOutcome: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "activity_total",
+			Help:      "Number of executed activities by outcome",
+		}, append(activityLabels, "outcome")),
+		activityDeadline: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "activity_deadline_exceeded_total",
+			Help:      "Number of activity workers killed because the activity deadline plus the grace period was exceeded",
+		}, activityLabels),
+		workflowEncode: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_encode_seconds",
+			Help:      "Workflow worker frame encoding time",
+		}, workflowLabels),
+		workflowExec: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_exec_seconds",
+			Help:      "Time spent by the workflow worker processing a frame",
+		}, workflowLabels),
+		workflowDecode: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_decode_seconds",
+			Help:      "Workflow worker frame decoding time",
+		}, workflowLabels),
+		workflowMessages: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_frame_messages",
+			Help:      "Number of messages per frame exchanged with the workflow worker",
+			Buckets:   messagesBuckets,
+		}, append(workflowLabels, "direction")),
+		workflowCommands: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_commands_total",
+			Help:      "Number of commands received from the workflow worker",
+		}, append(workflowLabels, "command")),
+		workflowFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_task_failures_total",
+			Help:      "Number of failed workflow tasks by the failure reason and outcome (retry or fail the workflow)",
+		}, append(workflowLabels, "reason", "outcome")),
+		workflowTimeouts: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "workflow_worker_timeouts_total",
+			Help:      "Number of times the workflow worker was killed because it did not respond in time",
+		}, workflowLabels),
+	}
+}
+
+// Collectors returns prometheus collectors for the RoadRunner metrics plugin.
+func (m *Metrics) Collectors() []prom.Collector {
+	return []prom.Collector{
+		m.activityExecution,
+		m.activitySerialization,
+		m.activityQueueWait,
+		m.activityThrottled,
+		m.activityOutcome,
+		m.activityDeadline,
+		m.workflowEncode,
+		m.workflowExec,
+		m.workflowDecode,
+		m.workflowMessages,
+		m.workflowCommands,
+		m.workflowFailures,
+		m.workflowTimeouts,
+	}
+}
+
+// activity execution metrics, mh is the activity metrics handler which is already tagged with the activity type and
+// the task queue.
+type activityMetrics struct {
+	m         *Metrics
+	mh        temporalClient.MetricsHandler
+	name      string
+	taskQueue string
+}
+
+func (m *Metrics) activity(mh temporalClient.MetricsHandler, name, taskQueue string) *activityMetrics {
+	return &activityMetrics{
+		m:         m,
+		mh:        mh,
+		name:      name,
+		taskQueue: taskQueue,
+	}
+}
+
+func (am *activityMetrics) execution(d time.Duration) {
+	am.timer(activityExecutionLatency, am.m.activityExecution, d)
+}
+
+func (am *activityMetrics) serialization(d time.Duration) {
+	am.timer(activitySerializationLatency, am.m.activitySerialization, d)
+}
+
+func (am *activityMetrics) queueWait(d time.Duration) {
+	am.timer(activityQueueWaitLatency, am.m.activityQueueWait, d)
+}
+
+func (am *activityMetrics) throttled(d time.Duration) {
+	am.timer(activityThrottledLatency, am.m.activityThrottled, d)
+}
+
+func (am *activityMetrics) outcome(outcome string) {
+	switch outcome {
+	case outcomeSucceed:
+		am.counter(activitySucceed)
+	case outcomeFailed:
+		am.counter(activityFailed)
+	case outcomeResultPending:
+		am.counter(activityResultPending)
+	}
+
+	am.m.activityOutcome.WithLabelValues(am.name, am.taskQueue, outcome).Inc()
+}
+
+func (am *activityMetrics) deadlineExceeded() {
+	am.counter(activityDeadlineExceeded)
+	am.m.activityDeadline.WithLabelValues(am.name, am.taskQueue).Inc()
+}
+
+func (am *activityMetrics) timer(name string, hv *prom.HistogramVec, d time.Duration) {
+	if am.m.handler && am.mh != nil {
+		am.mh.Timer(name).Record(d)
+	}
+
+	hv.WithLabelValues(am.name, am.taskQueue).Observe(d.Seconds())
+}
+
+func (am *activityMetrics) counter(name string) {
+	if am.m.handler && am.mh != nil {
+		am.mh.Counter(name).Inc(1)
+	}
+}
+
+// workflow task metrics, mh is the replay-aware workflow metrics handler which is already tagged with the workflow
+// type and the task queue. Prometheus collectors are not updated during replay.
+type workflowMetrics struct {
+	m            *Metrics
+	mh           temporalClient.MetricsHandler
+	workflowType string
+	taskQueue    string
+	replaying    func() bool
+}
+
+func (m *Metrics) workflow(mh temporalClient.MetricsHandler, workflowType, taskQueue string, replaying func() bool) *workflowMetrics {
+	return &workflowMetrics{
+		m:            m,
+		mh:           mh,
+		workflowType: workflowType,
+		taskQueue:    taskQueue,
+		replaying:    replaying,
+	}
+}
+
+func (wm *workflowMetrics) encode(d time.Duration) {
+	wm.timer(workflowEncodeLatency, wm.m.workflowEncode, d)
+}
+
+func (wm *workflowMetrics) exec(d time.Duration) {
+	wm.timer(workflowExecLatency, wm.m.workflowExec, d)
+}
+
+func (wm *workflowMetrics) decode(d time.Duration) {
+	wm.timer(workflowDecodeLatency, wm.m.workflowDecode, d)
+}
+
+// frame records the number of messages sent to and received from the workflow worker.
+func (wm *workflowMetrics) frame(sent, received int) {
+	if wm.handler() {
+		wm.mh.Counter(workflowFrames).Inc(1)
+	}
+
+	if wm.replaying() {
+		return
+	}
+
+	if wm.handler() {
+		// the scope is not replay-aware, histograms are not exposed by the metrics handler
+		scope := sdktally.ScopeFromHandler(wm.mh)
+		scope.Histogram(workflowMessagesSent, messagesBuckets).RecordValue(float64(sent))
+		scope.Histogram(workflowMessagesReceived, messagesBuckets).RecordValue(float64(received))
+	}
+
+	wm.m.workflowMessages.WithLabelValues(wm.workflowType, wm.taskQueue, "sent").Observe(float64(sent))
+	wm.m.workflowMessages.WithLabelValues(wm.workflowType, wm.taskQueue, "received").Observe(float64(received))
+}
+
+func (wm *workflowMetrics) command(name string) {
+	if wm.handler() {
+		wm.mh.WithTags(map[string]string{"command": name}).Counter(workflowCommands).Inc(1)
+	}
+
+	if wm.replaying() {
+		return
+	}
+
+	wm.m.workflowCommands.WithLabelValues(wm.workflowType, wm.taskQueue, name).Inc()
+}
+
+// taskFailure records the failed workflow task, reason is one of: panic, deterministic, transient; outcome is one of:
+// retry, fail.
+func (wm *workflowMetrics) taskFailure(reason, outcome string) {
+	if wm.handler() {
+		wm.mh.WithTags(map[string]string{"reason": reason, "outcome": outcome}).Counter(workflowTaskFailures).Inc(1)
+	}
+
+	if wm.replaying() {
+		return
+	}
+
+	wm.m.workflowFailures.WithLabelValues(wm.workflowType, wm.taskQueue, reason, outcome).Inc()
+}
+
+func (wm *workflowMetrics) workerTimeout() {
+	if wm.handler() {
+		wm.mh.Counter(workflowWorkerTimeouts).Inc(1)
+	}
+
+	wm.m.workflowTimeouts.WithLabelValues(wm.workflowType, wm.taskQueue).Inc()
+}
+
+func (wm *workflowMetrics) timer(name string, hv *prom.HistogramVec, d time.Duration) {
+	if wm.handler() {
+		wm.mh.Timer(name).Record(d)
+	}
+
+	if wm.replaying() {
+		return
+	}
+
+	hv.WithLabelValues(wm.workflowType, wm.taskQueue).Observe(d.Seconds())
+}
+
+func (wm *workflowMetrics) handler() bool {
+	return wm.m.handler && wm.mh != nil
+}
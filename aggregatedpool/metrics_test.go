@@ -0,0 +1,36 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally/v4"
+	sdktally "go.temporal.io/sdk/contrib/tally"
+)
+
+func Test_FrameMetrics(t *testing.T) {
+	replaying := false
+	scope := tally.NewTestScope("", nil)
+	wm := NewMetrics(true).workflow(sdktally.NewMetricsHandler(scope), "wf", "default", func() bool { return replaying })
+
+	wm.frame(3, 1)
+	replaying = true
+	wm.frame(3, 1)
+
+	histograms := scope.Snapshot().Histograms()
+	require.Contains(t, histograms, workflowMessagesSent+"+")
+	require.Contains(t, histograms, workflowMessagesReceived+"+")
+	assert.Equal(t, int64(1), histograms[workflowMessagesSent+"+"].Values()[5])
+	assert.Equal(t, int64(1), histograms[workflowMessagesReceived+"+"].Values()[1])
+}
+
+func Test_FrameMetricsNoHandler(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	wm := NewMetrics(false).workflow(sdktally.NewMetricsHandler(scope), "wf", "default", func() bool { return false })
+
+	wm.frame(3, 1)
+
+	assert.Empty(t, scope.Snapshot().Histograms())
+	assert.Empty(t, scope.Snapshot().Counters())
+}
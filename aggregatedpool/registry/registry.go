@@ -41,3 +41,17 @@ func (c *IDRegistry) Push(id uint64, w bindings.WorkflowExecution, err error) {
 		c.Unlock()
 	}
 }
+
+// Executions returns started child workflow executions keyed by the ExecuteChildWorkflow command ID.
+func (c *IDRegistry) Executions() map[uint64]bindings.WorkflowExecution {
+	executions := make(map[uint64]bindings.WorkflowExecution)
+	c.ids.Range(func(key, value interface{}) bool {
+		e := value.(entry)
+		if e.err == nil {
+			executions[key.(uint64)] = e.w
+		}
+		return true
+	})
+
+	return executions
+}
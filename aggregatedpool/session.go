@@ -0,0 +1,268 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// the session worker protocol of the go.temporal.io/sdk/internal/session.go, the SDK does not export it and creates
+// sessions only within the Go workflow context. Test_SessionProtocol checks the names against the SDK session worker.
+const (
+	sessionCreationActivityName    string = "internalSessionCreationActivity"
+	sessionCompletionActivityName  string = "internalSessionCompletionActivity"
+	sessionCreationTaskQueueSuffix string = "__internal_session_creation"
+
+	defaultSessionHeartbeatTimeout = time.Second * 20
+	sessionCompletionTimeout       = time.Second * 3
+)
+
+type sessionState int
+
+const (
+	sessionStateCreating sessionState = iota
+	sessionStateOpen
+	sessionStateFailed
+)
+
+// SessionInfo sent back to the worker as the CreateSession command result.
+type SessionInfo struct {
+	SessionID string `json:"sessionId"`
+	HostName  string `json:"hostName"`
+	TaskQueue string `json:"taskQueue"`
+}
+
+// sent by the session creation activity as a signal, keep in sync with the sdk sessionCreationResponse
+type sessionCreationResponse struct {
+	Taskqueue  string
+	HostName   string
+	ResourceID string
+}
+
+type session struct {
+	info  *workflow.SessionInfo
+	state sessionState
+	// resource specific task queue
+	taskQueue string
+	// ID of the CreateSession command
+	cmdID uint64
+	// creation activity, running for the whole session lifetime
+	activityID bindings.ActivityID
+	// IDs of the ExecuteActivity commands scheduled within the session
+	activities []uint64
+}
+
+// createSession schedules the session creation activity, the result is sent to the worker when the session worker
+// signals the creation response.
+func (wp *Workflow) createSession(id uint64, cmd *internal.CreateSession) error {
+	const op = errors.Op("workflow_create_session")
+
+	if cmd.ExecutionTimeout == 0 || cmd.CreationTimeout == 0 {
+		return wp.respondError(id, errors.E(op, errors.Str("session execution and creation timeouts are required")))
+	}
+
+	taskQueue := cmd.TaskQueue
+	if taskQueue == "" {
+		taskQueue = wp.env.WorkflowInfo().TaskQueueName
+	}
+
+	heartbeatTimeout := cmd.HeartbeatTimeout
+	if heartbeatTimeout == 0 {
+		heartbeatTimeout = defaultSessionHeartbeatTimeout
+	}
+
+	sessionID, err := wp.sessionID()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	input, err := wp.env.GetDataConverter().ToPayloads(sessionID)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	initialInterval := time.Second
+	maximumInterval := time.Second * 10
+
+	s := &session{
+		info:  &workflow.SessionInfo{SessionID: sessionID},
+		state: sessionStateCreating,
+		cmdID: id,
+	}
+
+	s.activityID = wp.env.ExecuteActivity(bindings.ExecuteActivityParams{
+		ExecuteActivityOptions: bindings.ExecuteActivityOptions{
+			TaskQueueName:          taskQueue + sessionCreationTaskQueueSuffix,
+			ScheduleToStartTimeout: cmd.CreationTimeout,
+			StartToCloseTimeout:    cmd.ExecutionTimeout,
+			HeartbeatTimeout:       heartbeatTimeout,
+			// retry only when there are too many outstanding sessions on the worker
+			RetryPolicy: &commonpb.RetryPolicy{
+				InitialInterval:        &initialInterval,
+				BackoffCoefficient:     1.1,
+				MaximumInterval:        &maximumInterval,
+				NonRetryableErrorTypes: []string{"TemporalTimeout:StartToClose", "TemporalTimeout:Heartbeat"},
+			},
+		},
+		ActivityType: bindings.ActivityType{Name: sessionCreationActivityName},
+		Input:        input,
+	}, wp.createSessionCallback(s))
+
+	wp.sessions[sessionID] = s
+
+	wp.canceller.Register(id, func() error {
+		wp.env.RequestCancelActivity(s.activityID)
+		return nil
+	})
+
+	return nil
+}
+
+// sessionID generates the session ID as the SDK does, the ID is recorded as the side effect to be the same on replay.
+func (wp *Workflow) sessionID() (string, error) {
+	var sessionID string
+	var err error
+
+	// the side effect callback is called synchronously
+	wp.env.SideEffect(func() (*commonpb.Payloads, error) {
+		return wp.env.GetDataConverter().ToPayloads(uuid.NewString())
+	}, func(result *commonpb.Payloads, errS error) {
+		if errS != nil {
+			err = errS
+			return
+		}
+
+		err = wp.env.GetDataConverter().FromPayloads(result, &sessionID)
+	})
+
+	return sessionID, err
+}
+
+// completeSession cancels the session activities and releases the session worker resources.
+func (wp *Workflow) completeSession(id uint64, cmd *internal.CompleteSession) error {
+	const op = errors.Op("workflow_complete_session")
+
+	s, ok := wp.sessions[cmd.SessionID]
+	if ok && s.state == sessionStateOpen {
+		delete(wp.sessions, cmd.SessionID)
+		wp.env.RemoveSession(cmd.SessionID)
+
+		err := wp.canceller.Cancel(s.activities...)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.env.RequestCancelActivity(s.activityID)
+
+		input, err := wp.env.GetDataConverter().ToPayloads(cmd.SessionID)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		// the session worker does not know about the canceled creation activity until the next heartbeat
+		wp.env.ExecuteActivity(bindings.ExecuteActivityParams{
+			ExecuteActivityOptions: bindings.ExecuteActivityOptions{
+				TaskQueueName:          s.taskQueue,
+				ScheduleToStartTimeout: sessionCompletionTimeout,
+				StartToCloseTimeout:    sessionCompletionTimeout,
+			},
+			ActivityType: bindings.ActivityType{Name: sessionCompletionActivityName},
+			Input:        input,
+		}, func(_ *commonpb.Payloads, err error) {
+			if err != nil {
+				wp.log.Warn("complete session activity failed", zap.String("sessionID", cmd.SessionID), zap.Error(err))
+			}
+		})
+	}
+
+	result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+	wp.mq.PushResponse(id, result)
+
+	err := wp.flushQueue()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// sessionTaskQueue registers the activity command within the session and returns the session task queue.
+func (wp *Workflow) sessionTaskQueue(id uint64, sessionID string) (string, error) {
+	s, ok := wp.sessions[sessionID]
+	if !ok || s.state != sessionStateOpen {
+		return "", workflow.ErrSessionFailed
+	}
+
+	s.activities = append(s.activities, id)
+
+	return s.taskQueue, nil
+}
+
+// handleSessionSignal completes the CreateSession command with the session worker creation response.
+func (wp *Workflow) handleSessionSignal(s *session, input *commonpb.Payloads) {
+	resp := &sessionCreationResponse{}
+	err := wp.env.GetDataConverter().FromPayloads(input, resp)
+	if err != nil {
+		wp.createCallback(s.cmdID)(nil, err)
+		return
+	}
+
+	s.state = sessionStateOpen
+	s.taskQueue = resp.Taskqueue
+	s.info.HostName = resp.HostName
+	wp.env.AddSession(s.info)
+
+	result, err := wp.env.GetDataConverter().ToPayloads(&SessionInfo{
+		SessionID: s.info.SessionID,
+		HostName:  s.info.HostName,
+		TaskQueue: s.taskQueue,
+	})
+
+	wp.createCallback(s.cmdID)(result, err)
+}
+
+// the creation activity completes only when the session is completed, canceled or failed
+func (wp *Workflow) createSessionCallback(s *session) bindings.ResultHandler {
+	return func(_ *commonpb.Payloads, err error) {
+		switch s.state {
+		case sessionStateCreating:
+			// failed before the session worker responded
+			if err == nil {
+				err = errors.Str("session creation activity completed without the creation response")
+			}
+
+			delete(wp.sessions, s.info.SessionID)
+			wp.createCallback(s.cmdID)(nil, err)
+
+		case sessionStateOpen:
+			if _, ok := wp.sessions[s.info.SessionID]; !ok {
+				// completed by the CompleteSession command
+				return
+			}
+
+			wp.log.Debug("session failed", zap.String("sessionID", s.info.SessionID), zap.Error(err))
+			s.state = sessionStateFailed
+			wp.env.RemoveSession(s.info.SessionID)
+
+			errC := wp.canceller.Cancel(s.activities...)
+			if errC != nil {
+				wp.log.Error("session activities cancellation", zap.String("sessionID", s.info.SessionID), zap.Error(errC))
+			}
+
+		case sessionStateFailed:
+		}
+	}
+}
+
+// respondError sends the failure for the command to the worker immediately.
+func (wp *Workflow) respondError(id uint64, err error) error {
+	wp.mq.PushError(id, bindings.ConvertErrorToFailure(err, wp.env.GetDataConverter()))
+
+	return wp.flushQueue()
+}
@@ -0,0 +1,213 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/roadrunner-server/api/v2/payload"
+	"github.com/roadrunner-server/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/aggregatedpool/canceller"
+	"github.com/temporalio/roadrunner-temporal/aggregatedpool/queue"
+	"github.com/temporalio/roadrunner-temporal/internal"
+	"github.com/temporalio/roadrunner-temporal/internal/codec/proto"
+	commonpb "go.temporal.io/api/common/v1"
+	tActivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// emptyPool responds to the workflow worker calls with no commands.
+type emptyPool struct {
+	hungPool
+	codec Codec
+}
+
+func (p *emptyPool) Exec(_ *payload.Payload) (*payload.Payload, error) {
+	pld := &payload.Payload{}
+	err := p.codec.Encode(&internal.Context{}, pld)
+	return pld, err
+}
+
+type sessionEnv struct {
+	testEnv
+	activities []bindings.ExecuteActivityParams
+	callbacks  []bindings.ResultHandler
+	canceled   int
+	sessions   map[string]*workflow.SessionInfo
+}
+
+func (e *sessionEnv) GetDataConverter() converter.DataConverter {
+	return converter.GetDefaultDataConverter()
+}
+
+func (e *sessionEnv) SideEffect(f func() (*commonpb.Payloads, error), callback bindings.ResultHandler) {
+	callback(f())
+}
+
+func (e *sessionEnv) ExecuteActivity(params bindings.ExecuteActivityParams, callback bindings.ResultHandler) bindings.ActivityID {
+	e.activities = append(e.activities, params)
+	e.callbacks = append(e.callbacks, callback)
+	return bindings.ActivityID{}
+}
+
+func (e *sessionEnv) RequestCancelActivity(bindings.ActivityID) {
+	e.canceled++
+}
+
+func (e *sessionEnv) AddSession(info *workflow.SessionInfo) {
+	e.sessions[info.SessionID] = info
+}
+
+func (e *sessionEnv) RemoveSession(sessionID string) {
+	delete(e.sessions, sessionID)
+}
+
+func testSessionWorkflow() (*Workflow, *sessionEnv) {
+	codec := proto.NewCodec(zap.NewNop(), converter.GetDefaultDataConverter(), nil)
+	seqID := func() uint64 { return 100 }
+
+	wp := NewWorkflowDefinition(codec, converter.GetDefaultDataConverter(), &emptyPool{codec: codec}, zap.NewNop(), seqID, time.Second, time.Second, 0, NewMetrics(true), nil, false)
+	env := &sessionEnv{
+		testEnv: testEnv{info: &workflow.Info{
+			WorkflowType:  workflow.Type{Name: "wf"},
+			TaskQueueName: "default",
+		}},
+		sessions: make(map[string]*workflow.SessionInfo),
+	}
+
+	wp.env = env
+	wp.mq = queue.NewMessageQueue(seqID)
+	wp.wm = wp.metrics.workflow(nil, "wf", "default", wp.env.IsReplaying)
+	wp.canceller = new(canceller.Canceller)
+	wp.sessions = make(map[string]*session)
+	wp.inLoop = 1
+
+	return wp, env
+}
+
+// openSession creates the session and responds with the session worker creation signal.
+func openSession(t *testing.T, wp *Workflow, env *sessionEnv) string {
+	require.NoError(t, wp.createSession(1, &internal.CreateSession{ExecutionTimeout: time.Minute, CreationTimeout: time.Minute}))
+	require.Len(t, env.activities, 1)
+
+	var sessionID string
+	require.NoError(t, env.GetDataConverter().FromPayloads(env.activities[0].Input, &sessionID))
+
+	resp, err := env.GetDataConverter().ToPayloads(&sessionCreationResponse{Taskqueue: "resource", HostName: "host"})
+	require.NoError(t, err)
+	require.NoError(t, wp.handleSignal(sessionID, resp, nil))
+
+	return sessionID
+}
+
+func Test_SessionCreate(t *testing.T) {
+	wp, env := testSessionWorkflow()
+	sessionID := openSession(t, wp, env)
+
+	_, err := uuid.Parse(sessionID)
+	require.NoError(t, err)
+
+	params := env.activities[0]
+	assert.Equal(t, sessionCreationActivityName, params.ActivityType.Name)
+	assert.Equal(t, "default"+sessionCreationTaskQueueSuffix, params.TaskQueueName)
+	assert.Equal(t, defaultSessionHeartbeatTimeout, params.HeartbeatTimeout)
+	assert.Contains(t, env.sessions, sessionID)
+
+	msgs := wp.mq.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, uint64(1), msgs[0].ID)
+
+	info := &SessionInfo{}
+	require.NoError(t, env.GetDataConverter().FromPayloads(msgs[0].Payloads, info))
+	assert.Equal(t, SessionInfo{SessionID: sessionID, HostName: "host", TaskQueue: "resource"}, *info)
+
+	tq, err := wp.sessionTaskQueue(2, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "resource", tq)
+}
+
+func Test_SessionCreateFailed(t *testing.T) {
+	wp, env := testSessionWorkflow()
+	require.NoError(t, wp.createSession(1, &internal.CreateSession{ExecutionTimeout: time.Minute, CreationTimeout: time.Minute}))
+
+	env.callbacks[0](nil, errors.Str("session creation timeout"))
+
+	msgs := wp.mq.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, uint64(1), msgs[0].ID)
+	assert.NotNil(t, msgs[0].Failure)
+	assert.Empty(t, wp.sessions)
+}
+
+func Test_SessionComplete(t *testing.T) {
+	wp, env := testSessionWorkflow()
+	sessionID := openSession(t, wp, env)
+
+	require.NoError(t, wp.completeSession(3, &internal.CompleteSession{SessionID: sessionID}))
+
+	assert.NotContains(t, env.sessions, sessionID)
+	assert.Empty(t, wp.sessions)
+	assert.Equal(t, 1, env.canceled)
+	require.Len(t, env.activities, 2)
+	assert.Equal(t, sessionCompletionActivityName, env.activities[1].ActivityType.Name)
+	assert.Equal(t, "resource", env.activities[1].TaskQueueName)
+
+	// the creation activity is canceled, the session is not reported as failed
+	env.callbacks[0](nil, context.Canceled)
+	_, err := wp.sessionTaskQueue(4, sessionID)
+	assert.ErrorIs(t, err, workflow.ErrSessionFailed)
+}
+
+func Test_SessionFailed(t *testing.T) {
+	wp, env := testSessionWorkflow()
+	sessionID := openSession(t, wp, env)
+
+	_, err := wp.sessionTaskQueue(2, sessionID)
+	require.NoError(t, err)
+
+	// the session worker stopped heartbeating
+	env.callbacks[0](nil, errors.Str("session heartbeat timeout"))
+
+	assert.NotContains(t, env.sessions, sessionID)
+	assert.Equal(t, sessionStateFailed, wp.sessions[sessionID].state)
+	_, err = wp.sessionTaskQueue(3, sessionID)
+	assert.ErrorIs(t, err, workflow.ErrSessionFailed)
+}
+
+// the session protocol names match the ones used by the SDK session worker
+func Test_SessionProtocol(t *testing.T) {
+	s := testsuite.WorkflowTestSuite{}
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{EnableSessionWorker: true})
+
+	var started []*tActivity.Info
+	env.SetOnActivityStartedListener(func(info *tActivity.Info, _ context.Context, _ converter.EncodedValues) {
+		started = append(started, info)
+	})
+
+	var taskQueue string
+	env.ExecuteWorkflow(func(ctx workflow.Context) error {
+		taskQueue = workflow.GetInfo(ctx).TaskQueueName
+		ctx, err := workflow.CreateSession(ctx, &workflow.SessionOptions{ExecutionTimeout: time.Minute, CreationTimeout: time.Minute})
+		if err != nil {
+			return err
+		}
+
+		workflow.CompleteSession(ctx)
+		return nil
+	})
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, started, 2)
+	assert.Equal(t, sessionCreationActivityName, started[0].ActivityType.Name)
+	assert.Equal(t, taskQueue+sessionCreationTaskQueueSuffix, started[0].TaskQueue)
+	assert.Equal(t, sessionCompletionActivityName, started[1].ActivityType.Name)
+}
@@ -0,0 +1,108 @@
+package aggregatedpool
+
+import (
+	stderr "errors"
+
+	"github.com/roadrunner-server/errors"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"go.uber.org/zap"
+)
+
+const (
+	// the Panic command sent by the PHP worker
+	failureReasonPanic string = "panic"
+	// the error is reproduced on every attempt (PHP exception, invalid frame or command)
+	failureReasonDeterministic string = "deterministic"
+	// the workflow worker crashed or was not available
+	failureReasonTransient string = "transient"
+
+	failureOutcomeRetry string = "retry"
+	failureOutcomeFail  string = "fail"
+)
+
+// panicError is the error of the Panic command.
+type panicError struct {
+	err error
+}
+
+func (e *panicError) Error() string {
+	return e.err.Error()
+}
+
+func (e *panicError) Unwrap() error {
+	return e.err
+}
+
+// transientError is the workflow pool error other than the PHP worker error.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// poolError marks the pool execution error as transient unless it was returned by the PHP worker.
+func poolError(err error) error {
+	if errors.Is(errors.SoftJob, err) {
+		return err
+	}
+
+	return &transientError{err: err}
+}
+
+// classifyFailure returns the failure reason and the error to report, the Panic command error is reported as is.
+func classifyFailure(err error) (string, error) {
+	for e := err; e != nil; {
+		switch v := e.(type) {
+		case *panicError:
+			return failureReasonPanic, v.err
+		case *transientError:
+			return failureReasonTransient, err
+		case *errors.Error:
+			e = v.Err
+		default:
+			e = stderr.Unwrap(e)
+		}
+	}
+
+	return failureReasonDeterministic, err
+}
+
+// taskQueueKey identifies the task queue, the same task queue name may be used in several namespaces.
+type taskQueueKey struct {
+	namespace string
+	taskQueue string
+}
+
+// panicPolicy returns the panic policy of the workflow task queue.
+func (wp *Workflow) panicPolicy() worker.WorkflowPanicPolicy {
+	info := wp.env.WorkflowInfo()
+	if policy, ok := wp.panicPolicies.Load(taskQueueKey{namespace: info.Namespace, taskQueue: info.TaskQueueName}); ok {
+		return policy.(worker.WorkflowPanicPolicy)
+	}
+
+	return worker.BlockWorkflow
+}
+
+// taskFailed handles the workflow task error. Transient errors and errors under the block policy panic, so the
+// workflow task fails and is retried by the server. Otherwise, the workflow fails.
+func (wp *Workflow) taskFailed(err error) {
+	reason, err := classifyFailure(err)
+
+	if reason == failureReasonTransient || wp.panicPolicy() == worker.BlockWorkflow {
+		wp.wm.taskFailure(reason, failureOutcomeRetry)
+		panic(err)
+	}
+
+	wp.wm.taskFailure(reason, failureOutcomeFail)
+	wp.log.Error("workflow failed due to the fail workflow panic policy", zap.String("reason", reason), zap.Error(err))
+
+	wp.env.Complete(nil, temporal.NewNonRetryableApplicationError("workflow failed due to the fail workflow panic policy", reason, err))
+	wp.sendEvent(EventWorkflowFailed, wp.workflowEvent(err))
+}
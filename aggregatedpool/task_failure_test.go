@@ -0,0 +1,26 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClassifyFailure(t *testing.T) {
+	const op = errors.Op("flush_queue")
+
+	phpPanic := errors.Str("php panic")
+	reason, err := classifyFailure(&panicError{err: phpPanic})
+	assert.Equal(t, failureReasonPanic, reason)
+	assert.Equal(t, phpPanic, err)
+
+	reason, _ = classifyFailure(errors.E(op, poolError(errors.E(errors.WorkerAllocate, errors.Str("no free workers")))))
+	assert.Equal(t, failureReasonTransient, reason)
+
+	reason, _ = classifyFailure(errors.E(op, poolError(errors.E(errors.SoftJob, errors.Str("php exception")))))
+	assert.Equal(t, failureReasonDeterministic, reason)
+
+	reason, _ = classifyFailure(errors.E(op, errors.Str("undefined command")))
+	assert.Equal(t, failureReasonDeterministic, reason)
+}
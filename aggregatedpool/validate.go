@@ -0,0 +1,123 @@
+package aggregatedpool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/internal"
+	"go.temporal.io/sdk/worker"
+)
+
+// ValidateWorkerInfo checks the worker info before any worker is created and reports all problems at once:
+// empty workflow/activity names and names duplicated within the task queue, duplicated task queues, invalid options and
+// conflicting identities. The same workflow or activity may be served by several task queues.
+func ValidateWorkerInfo(wi []*internal.WorkerInfo) error {
+	const op = errors.Op("validate_worker_info")
+
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	taskQueues := make(map[string]struct{}, len(wi))
+	// identity -> task queue
+	identities := make(map[string]string)
+
+	for i := 0; i < len(wi); i++ {
+		taskQueue := wi[i].TaskQueue
+		if taskQueue == "" {
+			taskQueue = "default"
+		}
+
+		key := wi[i].Namespace + "/" + taskQueue
+		if _, ok := taskQueues[key]; ok {
+			report("task queue %q is declared more than once", taskQueue)
+		}
+		taskQueues[key] = struct{}{}
+
+		workflows := make(map[string]struct{}, len(wi[i].Workflows))
+		for j := 0; j < len(wi[i].Workflows); j++ {
+			name := wi[i].Workflows[j].Name
+			if name == "" {
+				report("task queue %q: workflow #%d has an empty name", taskQueue, j)
+				continue
+			}
+
+			if _, ok := workflows[name]; ok {
+				report("task queue %q: workflow %q is declared more than once", taskQueue, name)
+				continue
+			}
+			workflows[name] = struct{}{}
+		}
+
+		activities := make(map[string]struct{}, len(wi[i].Activities))
+
+		for j := 0; j < len(wi[i].Activities); j++ {
+			name := wi[i].Activities[j].Name
+			if name == "" {
+				report("task queue %q: activity #%d has an empty name", taskQueue, j)
+				continue
+			}
+
+			if _, ok := activities[name]; ok {
+				report("task queue %q: activity %q is declared more than once", taskQueue, name)
+				continue
+			}
+			activities[name] = struct{}{}
+		}
+
+		if identity := wi[i].Options.Identity; identity != "" {
+			if tq, ok := identities[identity]; ok {
+				report("identity %q is used by the task queues %q and %q", identity, tq, taskQueue)
+			}
+			identities[identity] = taskQueue
+		}
+
+		for _, problem := range validateOptions(&wi[i].Options) {
+			report("task queue %q: %s", taskQueue, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.E(op, errors.Errorf("invalid worker info:\n - %s", strings.Join(problems, "\n - ")))
+	}
+
+	return nil
+}
+
+func validateOptions(opts *worker.Options) []string {
+	var problems []string
+
+	nonNegative := map[string]float64{
+		"MaxConcurrentActivityExecutionSize":      float64(opts.MaxConcurrentActivityExecutionSize),
+		"WorkerActivitiesPerSecond":               opts.WorkerActivitiesPerSecond,
+		"MaxConcurrentLocalActivityExecutionSize": float64(opts.MaxConcurrentLocalActivityExecutionSize),
+		"WorkerLocalActivitiesPerSecond":          opts.WorkerLocalActivitiesPerSecond,
+		"TaskQueueActivitiesPerSecond":            opts.TaskQueueActivitiesPerSecond,
+		"MaxConcurrentActivityTaskPollers":        float64(opts.MaxConcurrentActivityTaskPollers),
+		"MaxConcurrentWorkflowTaskExecutionSize":  float64(opts.MaxConcurrentWorkflowTaskExecutionSize),
+		"MaxConcurrentWorkflowTaskPollers":        float64(opts.MaxConcurrentWorkflowTaskPollers),
+		"MaxConcurrentSessionExecutionSize":       float64(opts.MaxConcurrentSessionExecutionSize),
+		"StickyScheduleToStartTimeout":            float64(opts.StickyScheduleToStartTimeout),
+		"MaxHeartbeatThrottleInterval":            float64(opts.MaxHeartbeatThrottleInterval),
+		"DefaultHeartbeatThrottleInterval":        float64(opts.DefaultHeartbeatThrottleInterval),
+		"DeadlockDetectionTimeout":                float64(opts.DeadlockDetectionTimeout),
+	}
+
+	for name, value := range nonNegative {
+		if value < 0 {
+			problems = append(problems, fmt.Sprintf("%s should not be negative", name))
+		}
+	}
+
+	if opts.LocalActivityWorkerOnly && opts.EnableSessionWorker {
+		problems = append(problems, "session worker can't be enabled for the local activity only worker")
+	}
+
+	// keep the report stable
+	sort.Strings(problems)
+
+	return problems
+}
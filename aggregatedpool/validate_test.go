@@ -0,0 +1,64 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/temporalio/roadrunner-temporal/internal"
+	"go.temporal.io/sdk/worker"
+)
+
+func Test_ValidateWorkerInfo(t *testing.T) {
+	wi := []*internal.WorkerInfo{
+		{
+			TaskQueue:  "default",
+			Workflows:  []internal.WorkflowInfo{{Name: "wf"}},
+			Activities: []internal.ActivityInfo{{Name: "act"}},
+		},
+		{
+			// the same workflow and activity on another task queue
+			TaskQueue:  "other",
+			Workflows:  []internal.WorkflowInfo{{Name: "wf"}},
+			Activities: []internal.ActivityInfo{{Name: "act"}},
+		},
+		{
+			// the same task queue in another namespace
+			Namespace: "ns",
+			TaskQueue: "other",
+		},
+		{
+			TaskQueue: "empty",
+		},
+	}
+
+	assert.NoError(t, ValidateWorkerInfo(wi))
+}
+
+func Test_ValidateWorkerInfoReportsAll(t *testing.T) {
+	wi := []*internal.WorkerInfo{
+		{
+			TaskQueue: "default",
+			Options:   worker.Options{Identity: "worker", MaxConcurrentActivityTaskPollers: -1},
+			Workflows: []internal.WorkflowInfo{{Name: "wf"}, {Name: ""}, {Name: "wf"}},
+		},
+		{
+			TaskQueue:  "other",
+			Options:    worker.Options{Identity: "worker"},
+			Workflows:  []internal.WorkflowInfo{{Name: "wf"}},
+			Activities: []internal.ActivityInfo{{Name: "act"}, {Name: "act"}},
+		},
+		{
+			TaskQueue: "other",
+		},
+	}
+
+	err := ValidateWorkerInfo(wi)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `task queue "default": workflow #1 has an empty name`)
+	assert.Contains(t, err.Error(), `task queue "default": MaxConcurrentActivityTaskPollers should not be negative`)
+	assert.Contains(t, err.Error(), `task queue "default": workflow "wf" is declared more than once`)
+	assert.Contains(t, err.Error(), `task queue "other": activity "act" is declared more than once`)
+	assert.Contains(t, err.Error(), `identity "worker" is used by the task queues "default" and "other"`)
+	assert.Contains(t, err.Error(), `task queue "other" is declared more than once`)
+	assert.NotContains(t, err.Error(), `task queue "other": workflow "wf"`)
+}
@@ -2,6 +2,9 @@ package aggregatedpool
 
 import (
 	"context"
+	"math"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,12 +16,14 @@ import (
 	"github.com/temporalio/roadrunner-temporal/aggregatedpool/queue"
 	"github.com/temporalio/roadrunner-temporal/aggregatedpool/registry"
 	"github.com/temporalio/roadrunner-temporal/internal"
+	"github.com/temporalio/roadrunner-temporal/internal/logger"
 	commonpb "go.temporal.io/api/common/v1"
 	tActivity "go.temporal.io/sdk/activity"
 	temporalClient "go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
 	bindings "go.temporal.io/sdk/internalbindings"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // implements WorkflowDefinition interface
@@ -35,10 +40,29 @@ import (
 
 type Callback func() error
 
+// CachedWorkflow describes a workflow execution kept in the sticky workflow cache.
+type CachedWorkflow struct {
+	WorkflowID   string    `json:"workflowId"`
+	RunID        string    `json:"runId"`
+	WorkflowType string    `json:"workflowType"`
+	TaskQueue    string    `json:"taskQueue"`
+	Attempt      int32     `json:"attempt"`
+	CachedAt     time.Time `json:"cachedAt"`
+
+	// replay-aware workflow metrics handler
+	mh temporalClient.MetricsHandler
+}
+
 type Workflow struct {
-	codec  Codec
-	pool   pool.Pool
-	client temporalClient.Client
+	codec Codec
+	pool  pool.Pool
+	// local activities pool, the workflow pool by default
+	laPool pool.Pool
+	// local activities are bounded by the deadline plus the grace period, the workflow pool is never bounded
+	laBounded bool
+	laGrace   time.Duration
+	// free workers of the local activities pool shared with the activities, nil for the workflow pool
+	laSlots *WorkerSlots
 
 	env       bindings.WorkflowEnvironment
 	header    *commonpb.Header
@@ -50,24 +74,49 @@ type Workflow struct {
 	pipeline  []*internal.Message
 	callbacks []Callback
 	canceller *canceller.Canceller
+	sessions  map[string]*session
 	inLoop    uint32
 
 	dc converter.DataConverter
 
 	log          *zap.Logger
+	replayLogs   bool
 	graceTimeout time.Duration
+	// configured timeout of the workflow task execution, the deadlock detection timeout of the task is used when zero
+	taskTimeout time.Duration
+	// deadlock detection timeout passed to the current workflow task
+	deadlockTimeout time.Duration
+	// timeout of the worker calls outside the workflow task: queries, stack trace, destroy
+	queryTimeout time.Duration
 	mh           temporalClient.MetricsHandler
+	metrics      *Metrics
+	wm           *workflowMetrics
+	events       *Events
+	// the started event is sent on the first non-replay workflow task
+	startSent bool
+
+	// workflows in the sticky cache, shared by all instances
+	cache *sync.Map
+	// panic policies by the namespace and the task queue, shared by all instances
+	panicPolicies *sync.Map
 }
 
-func NewWorkflowDefinition(codec Codec, dc converter.DataConverter, pool pool.Pool, log *zap.Logger, seqID func() uint64, client temporalClient.Client, gt time.Duration) *Workflow {
+func NewWorkflowDefinition(codec Codec, dc converter.DataConverter, pool pool.Pool, log *zap.Logger, seqID func() uint64, gt, qt, tt time.Duration, m *Metrics, ev *Events, replayLogs bool) *Workflow {
 	return &Workflow{
-		client:       client,
-		log:          log,
-		sID:          seqID,
-		codec:        codec,
-		graceTimeout: gt,
-		dc:           dc,
-		pool:         pool,
+		events:        ev,
+		replayLogs:    replayLogs,
+		metrics:       m,
+		log:           log,
+		sID:           seqID,
+		codec:         codec,
+		graceTimeout:  gt,
+		queryTimeout:  qt,
+		taskTimeout:   tt,
+		dc:            dc,
+		pool:          pool,
+		laPool:        pool,
+		cache:         &sync.Map{},
+		panicPolicies: &sync.Map{},
 	}
 }
 
@@ -75,23 +124,93 @@ func NewWorkflowDefinition(codec Codec, dc converter.DataConverter, pool pool.Po
 // DO NOT USE THIS FUNCTION DIRECTLY!!!!
 func (wp *Workflow) NewWorkflowDefinition() bindings.WorkflowDefinition {
 	return &Workflow{
-		pool:  wp.pool,
-		codec: wp.codec,
-		log:   wp.log,
-		sID:   wp.sID,
+		pool:          wp.pool,
+		laPool:        wp.laPool,
+		laBounded:     wp.laBounded,
+		laGrace:       wp.laGrace,
+		laSlots:       wp.laSlots,
+		codec:         wp.codec,
+		log:           wp.log,
+		replayLogs:    wp.replayLogs,
+		sID:           wp.sID,
+		queryTimeout:  wp.queryTimeout,
+		taskTimeout:   wp.taskTimeout,
+		cache:         wp.cache,
+		panicPolicies: wp.panicPolicies,
+		metrics:       wp.metrics,
+		events:        wp.events,
 	}
 }
 
+// SetLocalActivityPool sets the pool executing local activities, should be called before workers are started. The worker
+// still executing the local activity after the deadline and the grace period is killed and replaced.
+func (wp *Workflow) SetLocalActivityPool(p pool.Pool, grace time.Duration, slots *WorkerSlots) {
+	wp.laPool = p
+	wp.laBounded = boundedExec(p)
+	wp.laGrace = grace
+	wp.laSlots = slots
+}
+
+// workflowLogger returns the child logger which carries the workflow execution fields, the logger drops entries while
+// the workflow is replaying unless logging in replay is enabled.
+func (wp *Workflow) workflowLogger(env bindings.WorkflowEnvironment) *zap.Logger {
+	info := env.WorkflowInfo()
+	log := wp.log.With(
+		zap.String("namespace", info.Namespace),
+		zap.String("taskQueue", info.TaskQueueName),
+		zap.String("workflowID", info.WorkflowExecution.ID),
+		zap.String("runID", info.WorkflowExecution.RunID),
+		zap.String("workflowType", info.WorkflowType.Name),
+		zap.Int32("attempt", info.Attempt),
+	)
+
+	if wp.replayLogs {
+		return log
+	}
+
+	return log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return logger.NewReplayAwareCore(core, env.IsReplaying)
+	}))
+}
+
+// CachedWorkflows returns workflow executions currently kept in the sticky workflow cache.
+func (wp *Workflow) CachedWorkflows() []*CachedWorkflow {
+	workflows := make([]*CachedWorkflow, 0, 10)
+	wp.cache.Range(func(_, value interface{}) bool {
+		workflows = append(workflows, value.(*CachedWorkflow))
+		return true
+	})
+
+	sort.Slice(workflows, func(i, j int) bool {
+		return workflows[i].CachedAt.Before(workflows[j].CachedAt)
+	})
+
+	return workflows
+}
+
 // Execute implementation must be asynchronous.
 func (wp *Workflow) Execute(env bindings.WorkflowEnvironment, header *commonpb.Header, input *commonpb.Payloads) {
-	wp.log.Debug("workflow execute", zap.String("runID", env.WorkflowInfo().WorkflowExecution.RunID), zap.Any("workflow info", env.WorkflowInfo()))
+	wp.log = wp.workflowLogger(env)
+	wp.log.Debug("workflow execute", zap.Any("workflow info", env.WorkflowInfo()))
 
 	wp.mh = env.GetMetricsHandler()
+	wp.wm = wp.metrics.workflow(wp.mh, env.WorkflowInfo().WorkflowType.Name, env.WorkflowInfo().TaskQueueName, env.IsReplaying)
 	wp.env = env
 	wp.header = header
 	wp.seqID = 0
 	wp.runID = env.WorkflowInfo().WorkflowExecution.RunID
 	wp.canceller = new(canceller.Canceller)
+	wp.cache.Store(wp.runID, &CachedWorkflow{
+		WorkflowID:   env.WorkflowInfo().WorkflowExecution.ID,
+		RunID:        wp.runID,
+		WorkflowType: env.WorkflowInfo().WorkflowType.Name,
+		TaskQueue:    env.WorkflowInfo().TaskQueueName,
+		Attempt:      env.WorkflowInfo().Attempt,
+		CachedAt:     time.Now(),
+		mh:           wp.mh,
+	})
+	wp.sessions = make(map[string]*session)
+	wp.startSent = false
 
 	// sequenceID shared for all pool workflows
 	wp.mq = queue.NewMessageQueue(wp.sID)
@@ -123,6 +242,32 @@ func (wp *Workflow) Execute(env bindings.WorkflowEnvironment, header *commonpb.H
 	)
 }
 
+// sendEvent sends the workflow event, events are not sent while the workflow is replaying (including queries on the
+// closed workflows).
+func (wp *Workflow) sendEvent(t EventType, ev *WorkflowEvent) {
+	if wp.env.IsReplaying() {
+		return
+	}
+
+	wp.events.Send(t, ev)
+}
+
+func (wp *Workflow) workflowEvent(err error) *WorkflowEvent {
+	info := wp.env.WorkflowInfo()
+	ev := &WorkflowEvent{
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		WorkflowType: info.WorkflowType.Name,
+		TaskQueue:    info.TaskQueueName,
+	}
+
+	if err != nil {
+		ev.Error = err.Error()
+	}
+
+	return ev
+}
+
 // OnWorkflowTaskStarted is called for each non timed out startWorkflowTask event.
 // Executed after all history events since the previous commands are applied to WorkflowDefinition
 // Application level code must be executed from this function only.
@@ -136,13 +281,21 @@ func (wp *Workflow) OnWorkflowTaskStarted(t time.Duration) {
 	}()
 
 	wp.log.Debug("workflow task started", zap.Duration("time", t))
+	wp.deadlockTimeout = t
+
+	if !wp.startSent {
+		wp.startSent = true
+		// replaying the first task means the workflow was started before and the state is restored from the history
+		wp.sendEvent(EventWorkflowStarted, wp.workflowEvent(nil))
+	}
 
 	var err error
 	// do not copy
 	for k := range wp.callbacks {
 		err = wp.callbacks[k]()
 		if err != nil {
-			panic(err)
+			wp.taskFailed(err)
+			return
 		}
 	}
 
@@ -150,7 +303,8 @@ func (wp *Workflow) OnWorkflowTaskStarted(t time.Duration) {
 
 	err = wp.flushQueue()
 	if err != nil {
-		panic(err)
+		wp.taskFailed(err)
+		return
 	}
 
 	for len(wp.pipeline) > 0 {
@@ -162,11 +316,26 @@ func (wp *Workflow) OnWorkflowTaskStarted(t time.Duration) {
 		}
 
 		if err != nil {
-			panic(err)
+			wp.taskFailed(err)
+			return
 		}
 	}
 }
 
+// workflowTaskTimeout returns the timeout of the workflow worker calls within the workflow task: the configured one or
+// the deadlock detection timeout. The SDK passes the unlimited timeout when the debug mode is enabled.
+func (wp *Workflow) workflowTaskTimeout() time.Duration {
+	if wp.taskTimeout > 0 {
+		return wp.taskTimeout
+	}
+
+	if wp.deadlockTimeout == math.MaxInt64 {
+		return 0
+	}
+
+	return wp.deadlockTimeout
+}
+
 // StackTrace of all coroutines owned by the Dispatcher instance.
 func (wp *Workflow) StackTrace() string {
 	result, err := wp.runCommand(
@@ -195,6 +364,7 @@ func (wp *Workflow) StackTrace() string {
 }
 
 func (wp *Workflow) Close() {
+	wp.cache.Delete(wp.runID)
 	// send destroy command
 	_, _ = wp.runCommand(internal.DestroyWorkflow{RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID}, nil, wp.header)
 	// flush queue
@@ -209,8 +379,8 @@ func (wp *Workflow) execute(ctx context.Context, args *commonpb.Payloads) (*comm
 	mh := tActivity.GetMetricsHandler(ctx)
 	// if the mh is not nil, record the RR metric
 	if mh != nil {
-		mh.Gauge(RrMetricName).Update(float64(wp.pool.(pool.Queuer).QueueSize()))
-		defer mh.Gauge(RrMetricName).Update(float64(wp.pool.(pool.Queuer).QueueSize()))
+		mh.Gauge(RrMetricName).Update(float64(wp.laPool.(pool.Queuer).QueueSize()))
+		defer mh.Gauge(RrMetricName).Update(float64(wp.laPool.(pool.Queuer).QueueSize()))
 	}
 
 	var msg = &internal.Message{
@@ -228,7 +398,7 @@ func (wp *Workflow) execute(ctx context.Context, args *commonpb.Payloads) (*comm
 		return nil, err
 	}
 
-	result, err := wp.pool.Exec(pld)
+	result, err := wp.execLocalActivity(ctx, pld)
 	if err != nil {
 		return nil, errors.E(op, err)
 	}
@@ -254,3 +424,22 @@ func (wp *Workflow) execute(ctx context.Context, args *commonpb.Payloads) (*comm
 
 	return retPld.Payloads, nil
 }
+
+// execLocalActivity executes the local activity by the pool within the activity deadline plus the grace period.
+func (wp *Workflow) execLocalActivity(ctx context.Context, pld *payload.Payload) (*payload.Payload, error) {
+	release, _, err := wp.laSlots.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	deadline, ok := ctx.Deadline()
+	if !wp.laBounded || !ok {
+		return wp.laPool.Exec(pld)
+	}
+
+	execCtx, cancel := context.WithDeadline(context.Background(), deadline.Add(wp.laGrace))
+	defer cancel()
+
+	return wp.laPool.ExecWithTTL(execCtx, pld)
+}
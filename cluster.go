@@ -0,0 +1,327 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/roadrunner-server/errors"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// cluster keeps clients of the temporal cluster, the default namespace client of the primary cluster is the
+// plugin client.
+type cluster struct {
+	mu   sync.Mutex
+	log  *zap.Logger
+	name string
+	// default namespace of the cluster
+	namespace string
+	opts      temporalClient.Options
+	lazy      bool
+
+	// default namespace client
+	client temporalClient.Client
+	// clients of the other namespaces
+	clients map[string]temporalClient.Client
+	// dials of the other namespaces clients
+	dials singleflight.Group
+}
+
+// initClusters creates clusters from the configuration, the first one is the primary.
+func (p *Plugin) initClusters(base temporalClient.Options) error {
+	const op = errors.Op("temporal_init_clusters")
+
+	p.clusters = make([]*cluster, 0, len(p.config.Clusters))
+	for i := 0; i < len(p.config.Clusters); i++ {
+		cfg := p.config.Clusters[i]
+
+		opts := base
+		opts.HostPort = cfg.Address
+		opts.Namespace = cfg.Namespace
+
+		if cfg.TLS != nil {
+			tlsCfg, err := cfg.TLS.config()
+			if err != nil {
+				return errors.E(op, errors.Errorf("cluster: %s, tls: %v", cfg.Name, err))
+			}
+
+			opts.ConnectionOptions.TLS = tlsCfg
+		}
+
+		p.clusters = append(p.clusters, &cluster{
+			log:       p.log,
+			name:      cfg.Name,
+			namespace: cfg.Namespace,
+			opts:      opts,
+			// secondary clusters are used only on failover or for polling, do not fail the plugin start when they are down
+			lazy:    i > 0 || (p.config.Dial != nil && p.config.Dial.Lazy),
+			clients: make(map[string]temporalClient.Client),
+		})
+	}
+
+	return nil
+}
+
+// validateClusters checks the clusters, the poll policy and the failover and dial intervals after the defaults are
+// applied.
+func validateClusters(cfg *Config) error {
+	switch cfg.Poll {
+	case ClusterPollPrimary, ClusterPollAll:
+	default:
+		return errors.Errorf("unknown clusters poll policy: %s, should be one of: %s, %s", cfg.Poll, ClusterPollPrimary, ClusterPollAll)
+	}
+
+	names := make(map[string]struct{}, len(cfg.Clusters))
+	for i := 0; i < len(cfg.Clusters); i++ {
+		if _, ok := names[cfg.Clusters[i].Name]; ok {
+			return errors.Errorf("cluster: %s, duplicate cluster name", cfg.Clusters[i].Name)
+		}
+
+		names[cfg.Clusters[i].Name] = struct{}{}
+	}
+
+	if cfg.FailoverInterval <= 0 {
+		return errors.Errorf("failover interval should be positive, got: %s", cfg.FailoverInterval)
+	}
+
+	if cfg.Dial != nil {
+		if cfg.Dial.InitialInterval <= 0 || cfg.Dial.MaxInterval <= 0 {
+			return errors.Errorf("dial intervals should be positive, initial: %s, max: %s", cfg.Dial.InitialInterval, cfg.Dial.MaxInterval)
+		}
+
+		if cfg.Dial.InitialInterval > cfg.Dial.MaxInterval {
+			return errors.Errorf("dial initial interval: %s is greater than the max interval: %s", cfg.Dial.InitialInterval, cfg.Dial.MaxInterval)
+		}
+
+		if cfg.Dial.MaxElapsedTime < 0 {
+			return errors.Errorf("dial max elapsed time should not be negative, got: %s", cfg.Dial.MaxElapsedTime)
+		}
+	}
+
+	return nil
+}
+
+func (c *cluster) setClient(client temporalClient.Client) {
+	c.mu.Lock()
+	c.client = client
+	c.mu.Unlock()
+}
+
+// namespaceClient returns the client for the namespace, empty namespace is the default one. Clients are created on the
+// first call.
+func (c *cluster) namespaceClient(namespace string) (temporalClient.Client, error) {
+	const op = errors.Op("temporal_cluster_client")
+
+	if namespace == "" || namespace == c.namespace {
+		return c.defaultClient()
+	}
+
+	c.mu.Lock()
+	tc, ok := c.clients[namespace]
+	c.mu.Unlock()
+	if ok {
+		return tc, nil
+	}
+
+	// the dial blocks until the server responds, concurrent callers of the namespace share the dial and other
+	// namespaces are served meanwhile
+	v, err, _ := c.dials.Do(namespace, func() (interface{}, error) {
+		c.mu.Lock()
+		tc, ok := c.clients[namespace]
+		c.mu.Unlock()
+		// created by the previous dial
+		if ok {
+			return tc, nil
+		}
+
+		opts := c.opts
+		opts.Namespace = namespace
+
+		var err error
+		if c.lazy {
+			tc, err = temporalClient.NewLazyClient(opts)
+		} else {
+			tc, err = temporalClient.Dial(opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.clients[namespace] = tc
+		c.mu.Unlock()
+
+		c.log.Info("temporal client created", zap.String("cluster", c.name), zap.String("namespace", namespace))
+
+		return tc, nil
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return v.(temporalClient.Client), nil
+}
+
+func (c *cluster) defaultClient() (temporalClient.Client, error) {
+	const op = errors.Op("temporal_cluster_client")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client == nil {
+		if !c.lazy {
+			return nil, errors.E(op, errors.Errorf("cluster: %s, temporal client is not connected", c.name))
+		}
+
+		tc, err := temporalClient.NewLazyClient(c.opts)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+
+		c.client = tc
+	}
+
+	return c.client, nil
+}
+
+func (c *cluster) healthy() bool {
+	tc, err := c.namespaceClient("")
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	_, err = tc.CheckHealth(ctx, &temporalClient.CheckHealthRequest{})
+	if err != nil {
+		c.log.Debug("temporal cluster health check", zap.String("cluster", c.name), zap.Error(err))
+		return false
+	}
+
+	return true
+}
+
+// close closes the cluster clients, the default client is closed only when it is not the plugin client.
+func (c *cluster) close(closeDefault bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ns, tc := range c.clients {
+		tc.Close()
+		delete(c.clients, ns)
+	}
+
+	if closeDefault && c.client != nil {
+		c.client.Close()
+		c.client = nil
+	}
+}
+
+// polledClusters returns clusters polled by the workers according to the poll policy.
+func (p *Plugin) polledClusters() []*cluster {
+	if p.config.Poll == ClusterPollAll {
+		return p.clusters
+	}
+
+	return p.clusters[:1]
+}
+
+// rpcClient returns the client of the active cluster, RPC client calls fail over to the secondary clusters when the
+// primary is not healthy.
+func (p *Plugin) rpcClient(namespace string) (temporalClient.Client, error) {
+	return p.clusters[atomic.LoadUint32(&p.activeCluster)].namespaceClient(namespace)
+}
+
+// heartbeatClusters returns clusters in the order heartbeats of activities not executed by the plugin are sent: the
+// active cluster first. The task token is known only to the cluster which issued the task.
+func (p *Plugin) heartbeatClusters() []*cluster {
+	active := atomic.LoadUint32(&p.activeCluster)
+
+	clusters := make([]*cluster, 0, len(p.clusters))
+	clusters = append(clusters, p.clusters[active])
+	for i := 0; i < len(p.clusters); i++ {
+		if uint32(i) != active {
+			clusters = append(clusters, p.clusters[i])
+		}
+	}
+
+	return clusters
+}
+
+// rpcNamespace returns the default namespace of the active cluster.
+func (p *Plugin) rpcNamespace() string {
+	return p.clusters[atomic.LoadUint32(&p.activeCluster)].namespace
+}
+
+// failover switches the active cluster to the first healthy one in the configuration order, so RPC client calls return
+// to the primary when it recovers.
+func (p *Plugin) failover() {
+	if len(p.clusters) < 2 {
+		return
+	}
+
+	active := atomic.LoadUint32(&p.activeCluster)
+	for i := 0; i < len(p.clusters); i++ {
+		if !p.clusters[i].healthy() {
+			continue
+		}
+
+		if uint32(i) != active {
+			p.log.Warn("temporal cluster switched", zap.String("from", p.clusters[active].name), zap.String("to", p.clusters[i].name))
+			p.clusterSwitches.WithLabelValues(p.clusters[active].name, p.clusters[i].name).Inc()
+			atomic.StoreUint32(&p.activeCluster, uint32(i))
+		}
+
+		return
+	}
+
+	p.log.Error("no healthy temporal clusters", zap.String("active", p.clusters[active].name))
+}
+
+func newClusterSwitches() *prom.CounterVec {
+	return prom.NewCounterVec(prom.CounterOpts{
+		Namespace: namespace,
+		Name:      "cluster_switches_total",
+		Help:      "Number of RPC client switches between temporal clusters",
+	}, []string{"from", "to"})
+}
+
+func (t *TLS) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.Cert != "" || t.Key != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.RootCA != "" {
+		data, err := os.ReadFile(t.RootCA)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Str("failed to append the root CA certificates")
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
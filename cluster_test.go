@@ -0,0 +1,72 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+func Test_ValidateClusters(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   *Config
+		valid bool
+	}{
+		{name: "defaults", cfg: &Config{}, valid: true},
+		{name: "poll all", cfg: &Config{Poll: ClusterPollAll, Dial: &Dial{}}, valid: true},
+		{name: "unknown poll", cfg: &Config{Poll: "secondary"}},
+		{name: "duplicate names", cfg: &Config{Clusters: []*Cluster{{Name: "a", Address: "a:7233"}, {Name: "a", Address: "b:7233"}}}},
+		{name: "negative failover interval", cfg: &Config{FailoverInterval: -time.Second}},
+		{name: "negative dial interval", cfg: &Config{Dial: &Dial{InitialInterval: -time.Second}}},
+		{name: "initial above max interval", cfg: &Config{Dial: &Dial{InitialInterval: time.Minute, MaxInterval: time.Second}}},
+		{name: "negative dial max elapsed time", cfg: &Config{Dial: &Dial{MaxElapsedTime: -time.Second}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.InitDefault()
+			err := validateClusters(tt.cfg)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_NamespaceClientConcurrent(t *testing.T) {
+	c := &cluster{
+		log:       zap.NewNop(),
+		name:      "primary",
+		namespace: "default",
+		opts:      temporalClient.Options{HostPort: "127.0.0.1:7233"},
+		lazy:      true,
+		clients:   make(map[string]temporalClient.Client),
+	}
+	defer c.close(true)
+
+	clients := make([]temporalClient.Client, 10)
+	wg := sync.WaitGroup{}
+	for i := 0; i < len(clients); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tc, err := c.namespaceClient("other")
+			assert.NoError(t, err)
+			clients[i] = tc
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, c.clients, 1)
+	for i := 0; i < len(clients); i++ {
+		assert.Same(t, c.clients["other"], clients[i])
+	}
+}
@@ -1,15 +1,136 @@
 package roadrunner_temporal //nolint:revive,stylecheck
 
-import "github.com/roadrunner-server/sdk/v2/pool"
+import (
+	"time"
+
+	"github.com/roadrunner-server/sdk/v2/pool"
+)
 
 const (
 	MetricsTypeSummary string = "summary"
+
+	// ClusterPollPrimary - workers poll only the primary cluster
+	ClusterPollPrimary string = "primary"
+	// ClusterPollAll - workers poll all clusters
+	ClusterPollAll string = "all"
+
+	// LocalActivitiesWorkflow - local activities are executed by the workflow worker
+	LocalActivitiesWorkflow string = "workflow"
+	// LocalActivitiesActivity - local activities are executed by the activities pool
+	LocalActivitiesActivity string = "activity"
+	// LocalActivitiesDedicated - local activities are executed by the dedicated pool
+	LocalActivitiesDedicated string = "dedicated"
+
+	// MetricsDriverPrometheus starts the embedded prometheus listener on the Metrics.Address
+	MetricsDriverPrometheus string = "prometheus"
+	// MetricsDriverRR exports temporal metrics via the RoadRunner metrics plugin
+	MetricsDriverRR string = "rr"
+	// MetricsDriverStatsd pushes metrics to the statsd server on the Metrics.Address
+	MetricsDriverStatsd string = "statsd"
+	// MetricsDriverOTLP pushes metrics to the OpenTelemetry collector (OTLP/gRPC) on the Metrics.Address
+	MetricsDriverOTLP string = "otlp"
 )
 
 type Metrics struct {
+	// Driver is one of: prometheus (default), rr, statsd or otlp. The statsd driver appends tags to the metric name,
+	// the otlp driver records tally histogram samples as the upper bound of their bucket. The plugin activity and workflow
+	// metrics are exported via the RoadRunner metrics plugin regardless of the driver.
+	Driver  string `mapstructure:"driver"`
 	Address string `mapstructure:"address"`
 	Type    string `mapstructure:"type"`
 	Prefix  string `mapstructure:"prefix"`
+	// Interval defines how often metrics are reported to the sink, 1s by default (10s for the otlp driver)
+	Interval time.Duration `mapstructure:"interval"`
+	// Insecure disables TLS for the otlp driver
+	Insecure bool `mapstructure:"insecure"`
+}
+
+// TLS configuration of the cluster connection.
+type TLS struct {
+	Key        string `mapstructure:"key"`
+	Cert       string `mapstructure:"cert"`
+	RootCA     string `mapstructure:"root_ca"`
+	ServerName string `mapstructure:"server_name"`
+}
+
+// Cluster is the temporal cluster endpoint.
+type Cluster struct {
+	Name    string `mapstructure:"name"`
+	Address string `mapstructure:"address"`
+	// Namespace is the default namespace of the cluster, the plugin namespace by default
+	Namespace string `mapstructure:"namespace"`
+	TLS       *TLS   `mapstructure:"tls"`
+}
+
+// Namespace binds task queues to the namespace.
+type Namespace struct {
+	Name       string   `mapstructure:"name"`
+	TaskQueues []string `mapstructure:"task_queues"`
+}
+
+// Dial configures the temporal server connection retries. Without the dial section Serve fails when the server is
+// not reachable.
+type Dial struct {
+	// Lazy creates the client without connecting to the server, the client connects on the first call
+	Lazy bool `mapstructure:"lazy"`
+	// InitialInterval between connection attempts, 1s by default
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval between connection attempts, 30s by default
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+	// MaxElapsedTime after which the plugin stops retrying and fails, 0 (default) retries until the server is reachable
+	MaxElapsedTime time.Duration `mapstructure:"max_elapsed_time"`
+}
+
+// PayloadLogging controls how message payloads are written to the debug log.
+type PayloadLogging struct {
+	// Mode is one of: off (default), size, truncated. Command options and the context are logged in the truncated mode
+	// only.
+	Mode string `mapstructure:"mode"`
+	// MaxSize of the logged payload data in bytes for the truncated mode, 256 by default
+	MaxSize int `mapstructure:"max_size"`
+	// Redact replaces data of payloads having any of these metadata keys with the [redacted] placeholder
+	Redact []string `mapstructure:"redact"`
+}
+
+// AutoHeartbeat enables the host-side heartbeats for the activity type, heartbeats are recorded while the worker
+// executing the activity is busy and carry the last details recorded by the PHP worker.
+type AutoHeartbeat struct {
+	Activity string `mapstructure:"activity"`
+	// Interval between heartbeats, half of the activity heartbeat timeout by default
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// ActivityLimit caps executions of the activity type on this instance, zero values mean no limit.
+type ActivityLimit struct {
+	Activity      string `mapstructure:"activity"`
+	MaxConcurrent int    `mapstructure:"max_concurrent"`
+	// Rate of executions per second
+	Rate float64 `mapstructure:"rate"`
+	// Burst of executions allowed by the rate limit, 1 by default
+	Burst int `mapstructure:"burst"`
+}
+
+// LocalActivities configures the pool executing local activities, the InvokeLocalActivity command is the same for all
+// pools.
+type LocalActivities struct {
+	// RunOn is one of: workflow (default), activity, dedicated
+	RunOn string `mapstructure:"run_on"`
+	// Pool is the dedicated pool configuration, the activities pool configuration by default
+	Pool *pool.Config `mapstructure:"pool"`
+}
+
+// WorkflowTask configures the workflow task execution by the workflow worker.
+type WorkflowTask struct {
+	// Timeout after which the worker is killed and replaced, the sticky workflow cache is purged and workflows are
+	// replayed from the history. The deadlock detection timeout of the worker by default (1s by the SDK default).
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Session enables the session worker for the task queue.
+type Session struct {
+	TaskQueue string `mapstructure:"task_queue"`
+	// MaxConcurrentSessionExecutionSize defines the maximum number of sessions running on this host, 1000 by default.
+	MaxConcurrentSessionExecutionSize int `mapstructure:"max_concurrent_session_execution_size"`
 }
 
 // Config of the temporal client and dependent services.
@@ -19,6 +140,40 @@ type Config struct {
 	Metrics    *Metrics     `mapstructure:"metrics"`
 	Activities *pool.Config `mapstructure:"activities"`
 	CacheSize  int          `mapstructure:"cache_size"`
+	// ActivityDeadlineGrace is added to the activity deadline, the worker still executing the activity afterwards is
+	// killed and replaced. 5s by default. The supervised pool bounds activities only when the supervisor exec_ttl is set.
+	ActivityDeadlineGrace time.Duration `mapstructure:"activity_deadline_grace"`
+	Sessions              []*Session    `mapstructure:"sessions"`
+	// AutoHeartbeat is the opt-in host-side heartbeats configuration by the activity type
+	AutoHeartbeat []*AutoHeartbeat `mapstructure:"auto_heartbeat"`
+	// LocalActivities defines the pool executing local activities, the workflow worker by default
+	LocalActivities *LocalActivities `mapstructure:"local_activities"`
+	// ActivityLimits are the concurrency and rate limits by the activity type, enforced before the activity is sent to
+	// the pool
+	ActivityLimits []*ActivityLimit `mapstructure:"activity_limits"`
+	// Workers options by the task queue, merged with or override the options declared by the PHP worker
+	Workers []*WorkerOptions `mapstructure:"workers"`
+	// Clusters are temporal cluster endpoints, the first one is the primary. Address and Namespace define the only
+	// cluster when empty.
+	Clusters []*Cluster `mapstructure:"clusters"`
+	// Poll defines clusters polled by the workers: primary (default) or all. RPC client calls always target the
+	// primary cluster and fail over to the next healthy one.
+	Poll string `mapstructure:"poll"`
+	// FailoverInterval between the clusters health checks of the RPC client failover, 10s by default
+	FailoverInterval time.Duration `mapstructure:"failover_interval"`
+	// Namespaces binds task queues to namespaces other than the default one
+	Namespaces []*Namespace `mapstructure:"namespaces"`
+	// Dial retries configuration, workers are started in background once the server is reachable
+	Dial *Dial `mapstructure:"dial"`
+	// QueryTimeout bounds the workflow worker calls outside the workflow task (queries, stack traces), 10s by default.
+	// The worker is killed and replaced on timeout.
+	QueryTimeout time.Duration `mapstructure:"query_timeout"`
+	// WorkflowTask overrides the workflow task timeout
+	WorkflowTask *WorkflowTask `mapstructure:"workflow_task"`
+	// EnableLoggingInReplay keeps the plugin workflow logs while the workflow is replaying, suppressed by default.
+	EnableLoggingInReplay bool `mapstructure:"enable_logging_in_replay"`
+	// PayloadLogging is the payloads debug logging configuration, payloads are not logged by default
+	PayloadLogging *PayloadLogging `mapstructure:"payload_logging"`
 }
 
 func (c *Config) InitDefault() {
@@ -26,21 +181,139 @@ func (c *Config) InitDefault() {
 		c.Activities.InitDefaults()
 	}
 
+	if c.LocalActivities == nil {
+		c.LocalActivities = &LocalActivities{}
+	}
+
+	if c.LocalActivities.RunOn == "" {
+		c.LocalActivities.RunOn = LocalActivitiesWorkflow
+	}
+
+	if c.LocalActivities.RunOn == LocalActivitiesDedicated {
+		if c.LocalActivities.Pool == nil {
+			c.LocalActivities.Pool = &pool.Config{}
+			if c.Activities != nil {
+				*c.LocalActivities.Pool = *c.Activities
+				// the supervisor is modified by the pool defaults, so it's not shared with the activities pool
+				if c.Activities.Supervisor != nil {
+					sv := *c.Activities.Supervisor
+					c.LocalActivities.Pool.Supervisor = &sv
+				}
+			}
+		}
+
+		if c.LocalActivities.Pool.Command == "" && c.Activities != nil {
+			c.LocalActivities.Pool.Command = c.Activities.Command
+		}
+
+		c.LocalActivities.Pool.InitDefaults()
+	}
+
+	if c.ActivityDeadlineGrace == 0 {
+		c.ActivityDeadlineGrace = time.Second * 5
+	}
+
 	if c.CacheSize == 0 {
 		c.CacheSize = 10000
 	}
 
+	if c.QueryTimeout == 0 {
+		c.QueryTimeout = time.Second * 10
+	}
+
 	if c.Namespace == "" {
 		c.Namespace = "default"
 	}
 
+	if len(c.Clusters) == 0 {
+		c.Clusters = []*Cluster{{Address: c.Address}}
+	}
+
+	for i := 0; i < len(c.Clusters); i++ {
+		if c.Clusters[i].Namespace == "" {
+			c.Clusters[i].Namespace = c.Namespace
+		}
+
+		if c.Clusters[i].Name == "" {
+			c.Clusters[i].Name = c.Clusters[i].Address
+		}
+	}
+
+	// the primary cluster
+	c.Address = c.Clusters[0].Address
+	c.Namespace = c.Clusters[0].Namespace
+
+	if c.Poll == "" {
+		c.Poll = ClusterPollPrimary
+	}
+
+	if c.FailoverInterval == 0 {
+		c.FailoverInterval = healthCheckInterval
+	}
+
+	for i := 0; i < len(c.Workers); i++ {
+		if c.Workers[i].TaskQueue == "" {
+			c.Workers[i].TaskQueue = "default"
+		}
+
+		if c.Workers[i].Mode == "" {
+			c.Workers[i].Mode = WorkerOptionsMerge
+		}
+	}
+
+	for i := 0; i < len(c.Sessions); i++ {
+		if c.Sessions[i].TaskQueue == "" {
+			c.Sessions[i].TaskQueue = "default"
+		}
+	}
+
+	if c.Dial != nil {
+		if c.Dial.InitialInterval == 0 {
+			c.Dial.InitialInterval = time.Second
+		}
+
+		if c.Dial.MaxInterval == 0 {
+			c.Dial.MaxInterval = time.Second * 30
+		}
+	}
+
+	if c.PayloadLogging == nil {
+		c.PayloadLogging = &PayloadLogging{}
+	}
+
+	if c.PayloadLogging.Mode == "" {
+		c.PayloadLogging.Mode = "off"
+	}
+
+	if c.PayloadLogging.MaxSize == 0 {
+		c.PayloadLogging.MaxSize = 256
+	}
+
 	if c.Metrics != nil {
+		if c.Metrics.Driver == "" {
+			c.Metrics.Driver = MetricsDriverPrometheus
+		}
+
 		if c.Metrics.Type == "" {
 			c.Metrics.Type = MetricsTypeSummary
 		}
 
+		if c.Metrics.Interval == 0 {
+			c.Metrics.Interval = time.Second
+			if c.Metrics.Driver == MetricsDriverOTLP {
+				c.Metrics.Interval = time.Second * 10
+			}
+		}
+
 		if c.Metrics.Address == "" {
-			c.Metrics.Address = "127.0.0.1:9091"
+			switch c.Metrics.Driver {
+			case MetricsDriverStatsd:
+				c.Metrics.Address = "127.0.0.1:8125"
+			case MetricsDriverOTLP:
+				c.Metrics.Address = "127.0.0.1:4317"
+			default:
+				c.Metrics.Address = "127.0.0.1:9091"
+			}
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/roadrunner-server/errors"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+// connect establishes the temporal server connection with the exponential backoff and starts temporal workers.
+// The plugin reports not ready until workers are started. Errors are sent to the Serve error channel when the
+// max elapsed time is reached.
+func (p *Plugin) connect(opts temporalClient.Options, errCh chan error) {
+	const op = errors.Op("temporal_plugin_connect")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-p.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = p.config.Dial.InitialInterval
+	b.MaxInterval = p.config.Dial.MaxInterval
+	b.MaxElapsedTime = p.config.Dial.MaxElapsedTime
+
+	operation := func() error {
+		p.mu.RLock()
+		client := p.client
+		p.mu.RUnlock()
+
+		// do not hold the lock while connecting, so the status checks are not blocked
+		if client == nil {
+			var err error
+			client, err = temporalClient.Dial(opts)
+			if err != nil {
+				return err
+			}
+		} else {
+			// lazy client
+			hctx, hcancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer hcancel()
+
+			_, err := client.CheckHealth(hctx, &temporalClient.CheckHealthRequest{})
+			if err != nil {
+				return err
+			}
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if ctx.Err() != nil {
+			// stopped while connecting
+			if p.client == nil {
+				client.Close()
+			}
+
+			return backoff.Permanent(ctx.Err())
+		}
+
+		p.client = client
+		p.clusters[0].setClient(client)
+		p.log.Info("connected to temporal server", zap.String("address", p.config.Address))
+		atomic.StoreUint32(&p.connected, 1)
+
+		// workers might be started by the Reset
+		if atomic.LoadUint32(&p.started) == 1 {
+			return nil
+		}
+
+		err := p.initWorkers()
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+
+		return nil
+	}
+
+	notify := func(err error, next time.Duration) {
+		p.log.Warn("temporal server is unavailable, retrying", zap.String("address", p.config.Address), zap.Duration("next attempt", next), zap.Error(err))
+	}
+
+	err := backoff.RetryNotify(operation, backoff.WithContext(b, ctx), notify)
+	if err != nil {
+		if ctx.Err() != nil {
+			// plugin stopped
+			return
+		}
+
+		errCh <- errors.E(op, err)
+	}
+}
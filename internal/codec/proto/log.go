@@ -0,0 +1,108 @@
+package proto
+
+import (
+	"github.com/roadrunner-server/errors"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.uber.org/zap"
+)
+
+const (
+	// PayloadLogOff does not log payloads
+	PayloadLogOff string = "off"
+	// PayloadLogSize logs the number and the total size of payloads
+	PayloadLogSize string = "size"
+	// PayloadLogTruncated logs payloads data truncated to the max size
+	PayloadLogTruncated string = "truncated"
+
+	redacted string = "[redacted]"
+)
+
+// RedactFunc returns data to log instead of the payload data, value is the payload metadata value the func is
+// registered for.
+type RedactFunc func(value []byte, data []byte) []byte
+
+// PayloadLogger controls how message payloads are written to the debug log.
+type PayloadLogger struct {
+	mode    string
+	maxSize int
+	// metadata key -> redact func
+	redactors map[string]RedactFunc
+}
+
+// NewPayloadLogger creates the payload logger for the mode.
+func NewPayloadLogger(mode string, maxSize int) (*PayloadLogger, error) {
+	const op = errors.Op("new_payload_logger")
+
+	switch mode {
+	case PayloadLogOff, PayloadLogSize, PayloadLogTruncated:
+	default:
+		return nil, errors.E(op, errors.Errorf("unknown payload logging mode: %s, should be one of: %s, %s, %s", mode, PayloadLogOff, PayloadLogSize, PayloadLogTruncated))
+	}
+
+	return &PayloadLogger{
+		mode:      mode,
+		maxSize:   maxSize,
+		redactors: make(map[string]RedactFunc),
+	}, nil
+}
+
+// Redact registers the redact func for payloads with the metadata key, nil func replaces the data with the
+// [redacted] placeholder. Should be called before the codec is used.
+func (pl *PayloadLogger) Redact(metadataKey string, fn RedactFunc) {
+	if fn == nil {
+		fn = func(_, _ []byte) []byte {
+			return []byte(redacted)
+		}
+	}
+
+	pl.redactors[metadataKey] = fn
+}
+
+// verbose reports whether the command options and the context are logged, only the command name and the message ID
+// are logged otherwise.
+func (pl *PayloadLogger) verbose() bool {
+	return pl != nil && pl.mode == PayloadLogTruncated
+}
+
+// fields returns log fields for the payloads according to the mode.
+func (pl *PayloadLogger) fields(payloads *commonpb.Payloads) []zap.Field {
+	if pl == nil || pl.mode == PayloadLogOff || payloads == nil {
+		return nil
+	}
+
+	switch pl.mode {
+	case PayloadLogSize:
+		size := 0
+		for _, p := range payloads.GetPayloads() {
+			size += len(p.GetData())
+		}
+
+		return []zap.Field{zap.Int("payloads", len(payloads.GetPayloads())), zap.Int("payloads_size", size)}
+
+	case PayloadLogTruncated:
+		data := make([]string, 0, len(payloads.GetPayloads()))
+		for _, p := range payloads.GetPayloads() {
+			data = append(data, string(pl.data(p)))
+		}
+
+		return []zap.Field{zap.Strings("payloads", data)}
+	}
+
+	return nil
+}
+
+func (pl *PayloadLogger) data(p *commonpb.Payload) []byte {
+	data := p.GetData()
+	for k, v := range p.GetMetadata() {
+		if fn, ok := pl.redactors[k]; ok {
+			data = fn(v, data)
+			break
+		}
+	}
+
+	if pl.maxSize > 0 && len(data) > pl.maxSize {
+		return append(data[:pl.maxSize:pl.maxSize], "..."...)
+	}
+
+	return data
+}
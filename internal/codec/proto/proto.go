@@ -16,14 +16,16 @@ import (
 // Codec uses protobuf to exchange messages with underlying workers.
 type Codec struct {
 	log    *zap.Logger
+	pl     *PayloadLogger
 	dc     converter.DataConverter
 	frPool sync.Pool
 }
 
-// NewCodec creates new Proto communication Codec.
-func NewCodec(log *zap.Logger, dc converter.DataConverter) *Codec {
+// NewCodec creates new Proto communication Codec. Payloads are written to the debug log according to the payload logger.
+func NewCodec(log *zap.Logger, dc converter.DataConverter, pl *PayloadLogger) *Codec {
 	return &Codec{
 		log: log,
+		pl:  pl,
 		dc:  dc,
 		frPool: sync.Pool{
 			New: func() interface{} {
@@ -49,7 +51,6 @@ func (c *Codec) Encode(ctx *internal.Context, p *payload.Payload, msg ...*intern
 		if err != nil {
 			return err
 		}
-		c.log.Debug("outgoing message", zap.Uint64("id", frame.Id), zap.ByteString("data", p.Body), zap.ByteString("context", p.Context))
 		request.Messages[i] = frame
 	}
 
@@ -69,6 +70,18 @@ func (c *Codec) Encode(ctx *internal.Context, p *payload.Payload, msg ...*intern
 		return errors.E(errors.Op("encode_payload"), err)
 	}
 
+	// log after encoding, so the context and the frame size are known
+	if c.log.Core().Enabled(zap.DebugLevel) {
+		for i := 0; i < len(msg); i++ {
+			fields := append(c.pl.fields(msg[i].Payloads), zap.Uint64("id", msg[i].ID), zap.String("command", request.Messages[i].Command), zap.Int("frame_size", len(p.Body)))
+			if c.pl.verbose() {
+				fields = append(fields, zap.Any("options", msg[i].Command), zap.ByteString("context", p.Context))
+			}
+
+			c.log.Debug("outgoing message", fields...)
+		}
+	}
+
 	return nil
 }
 
@@ -88,12 +101,19 @@ func (c *Codec) Decode(pld *payload.Payload, result *[]*internal.Message) error
 
 	for _, f := range response.Messages {
 		msg, errM := c.parseMessage(f)
-
-		c.log.Debug("received message", zap.Any("command", msg.Command), zap.Uint64("id", msg.ID), zap.ByteString("data", pld.Body))
 		if errM != nil {
 			return errM
 		}
 
+		if c.log.Core().Enabled(zap.DebugLevel) {
+			fields := append(c.pl.fields(msg.Payloads), zap.Uint64("id", msg.ID), zap.String("command", f.Command), zap.Int("frame_size", len(pld.Body)))
+			if c.pl.verbose() {
+				fields = append(fields, zap.Any("options", msg.Command))
+			}
+
+			c.log.Debug("received message", fields...)
+		}
+
 		*result = append(*result, msg)
 	}
 
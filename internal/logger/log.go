@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log writes the entry sent by the PHP code. Unknown levels are logged as info, levels above error are logged as
+// error, so the PHP code can't panic or stop the process.
+func Log(log *zap.Logger, level, message string, context map[string]interface{}) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		lvl = zapcore.InfoLevel
+	}
+
+	if lvl > zapcore.ErrorLevel {
+		lvl = zapcore.ErrorLevel
+	}
+
+	ce := log.Check(lvl, message)
+	if ce == nil {
+		return
+	}
+
+	fields := make([]zap.Field, 0, len(context))
+	for k, v := range context {
+		fields = append(fields, zap.Any(k, v))
+	}
+
+	ce.Write(fields...)
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// ReplayAwareCore drops log entries while the workflow is replaying, the same way the Go SDK replay-aware logger does.
+type ReplayAwareCore struct {
+	zapcore.Core
+	replaying func() bool
+}
+
+func NewReplayAwareCore(core zapcore.Core, replaying func() bool) zapcore.Core {
+	return &ReplayAwareCore{
+		Core:      core,
+		replaying: replaying,
+	}
+}
+
+func (c *ReplayAwareCore) With(fields []zapcore.Field) zapcore.Core {
+	return &ReplayAwareCore{
+		Core:      c.Core.With(fields),
+		replaying: c.replaying,
+	}
+}
+
+func (c *ReplayAwareCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.replaying() {
+		return ce
+	}
+
+	return c.Core.Check(entry, ce)
+}
@@ -38,6 +38,11 @@ const (
 	signalExternalWorkflowCommand = "SignalExternalWorkflow"
 	cancelExternalWorkflowCommand = "CancelExternalWorkflow"
 
+	createSessionCommand   = "CreateSession"
+	completeSessionCommand = "CompleteSession"
+
+	logCommand = "Log"
+
 	cancelCommand = "Cancel"
 	panicCommand  = "Panic"
 )
@@ -166,6 +171,8 @@ type ExecuteActivity struct {
 	Name string `json:"name"`
 	// Options to run activity.
 	Options bindings.ExecuteActivityOptions `json:"options,omitempty"`
+	// SessionID routes the activity to the host which owns the session (optional).
+	SessionID string `json:"sessionId,omitempty"`
 }
 
 // ExecuteLocalActivityOptions .. since we use proto everywhere we need to convert Activity options (proto) to non-proto LA options
@@ -246,6 +253,33 @@ type CancelExternalWorkflow struct {
 	RunID      string `json:"runID"`
 }
 
+// CreateSession creates a session on one of the session workers of the task queue.
+type CreateSession struct {
+	// TaskQueue to create the session on, workflow task queue by default.
+	TaskQueue string `json:"taskQueue,omitempty"`
+	// ExecutionTimeout is the maximum amount of time the session can run (required).
+	ExecutionTimeout time.Duration `json:"executionTimeout"`
+	// CreationTimeout is the time the session creation can take before returning an error (required).
+	CreationTimeout time.Duration `json:"creationTimeout"`
+	// HeartbeatTimeout of the session, 20s by default.
+	HeartbeatTimeout time.Duration `json:"heartbeatTimeout,omitempty"`
+}
+
+// CompleteSession completes the session and releases the session worker resources.
+type CompleteSession struct {
+	// SessionID returned by the CreateSession command.
+	SessionID string `json:"sessionId"`
+}
+
+// Log writes the PHP workflow log entry to the plugin logger, the entry is dropped while the workflow is replaying.
+type Log struct {
+	// Level is one of: debug, info, warn, error.
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	// Context is the set of key-values attached to the entry.
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
 // Cancel one or multiple internal promises (activities, local activities, timers, child workflows).
 type Cancel struct {
 	// CommandIDs to be canceled.
@@ -383,6 +417,12 @@ func CommandName(cmd interface{}) (string, error) {
 		return signalExternalWorkflowCommand, nil
 	case CancelExternalWorkflow, *CancelExternalWorkflow:
 		return cancelExternalWorkflowCommand, nil
+	case CreateSession, *CreateSession:
+		return createSessionCommand, nil
+	case CompleteSession, *CompleteSession:
+		return completeSessionCommand, nil
+	case Log, *Log:
+		return logCommand, nil
 	case Cancel, *Cancel:
 		return cancelCommand, nil
 	case Panic, *Panic:
@@ -453,6 +493,15 @@ func InitCommand(name string) (interface{}, error) {
 	case cancelExternalWorkflowCommand:
 		return &CancelExternalWorkflow{}, nil
 
+	case createSessionCommand:
+		return &CreateSession{}, nil
+
+	case completeSessionCommand:
+		return &CompleteSession{}, nil
+
+	case logCommand:
+		return &Log{}, nil
+
 	case cancelCommand:
 		return &Cancel{}, nil
 
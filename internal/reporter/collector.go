@@ -0,0 +1,65 @@
+package reporter
+
+import (
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is the prometheus registerer for the tally reporter. Tally registers metric vectors lazily, on the first
+// emission, so Collector is exported to the RoadRunner metrics plugin once and collects every registered vector.
+type Collector struct {
+	mu         sync.RWMutex
+	collectors []prom.Collector
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		collectors: make([]prom.Collector, 0, 10),
+	}
+}
+
+// Register implements prometheus.Registerer.
+func (c *Collector) Register(collector prom.Collector) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.collectors = append(c.collectors, collector)
+
+	return nil
+}
+
+// MustRegister implements prometheus.Registerer.
+func (c *Collector) MustRegister(collectors ...prom.Collector) {
+	for i := 0; i < len(collectors); i++ {
+		_ = c.Register(collectors[i])
+	}
+}
+
+// Unregister implements prometheus.Registerer.
+func (c *Collector) Unregister(collector prom.Collector) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := 0; i < len(c.collectors); i++ {
+		if c.collectors[i] == collector {
+			c.collectors = append(c.collectors[:i], c.collectors[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Describe sends no descriptors, which makes the Collector unchecked: the set of metrics is not known upfront.
+func (c *Collector) Describe(_ chan<- *prom.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i := 0; i < len(c.collectors); i++ {
+		c.collectors[i].Collect(ch)
+	}
+}
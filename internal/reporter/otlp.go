@@ -0,0 +1,228 @@
+package reporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"go.uber.org/zap"
+)
+
+const instrumentationName string = "roadrunner-temporal"
+
+// OTLP is the tally reporter which pushes metrics to the OpenTelemetry collector over OTLP/gRPC.
+// Counters are exported as sums, gauges as the last observed value and timers and histograms as histograms. tally
+// histogram samples are recorded as the upper bound of their bucket.
+type OTLP struct {
+	log      *zap.Logger
+	exporter *otlpmetric.Exporter
+	ctrl     *controller.Controller
+	meter    metric.Meter
+
+	mu         sync.Mutex
+	counters   map[string]syncint64.Counter
+	histograms map[string]syncfloat64.Histogram
+	gauges     map[string]map[attribute.Distinct]*gaugeValue
+}
+
+type gaugeValue struct {
+	attrs []attribute.KeyValue
+	value float64
+}
+
+// NewOTLP starts the OTLP exporter, metrics are pushed to the endpoint every interval.
+func NewOTLP(endpoint string, insecure bool, interval time.Duration, log *zap.Logger) (*OTLP, error) {
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+
+	exporter, err := otlpmetricgrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl := controller.New(
+		processor.NewFactory(simple.NewWithHistogramDistribution(), exporter),
+		controller.WithExporter(exporter),
+		controller.WithCollectPeriod(interval),
+	)
+
+	err = ctrl.Start(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTLP{
+		log:        log,
+		exporter:   exporter,
+		ctrl:       ctrl,
+		meter:      ctrl.Meter(instrumentationName),
+		counters:   make(map[string]syncint64.Counter),
+		histograms: make(map[string]syncfloat64.Histogram),
+		gauges:     make(map[string]map[attribute.Distinct]*gaugeValue),
+	}, nil
+}
+
+// ReportCounter reports the counter delta since the last report.
+func (o *OTLP) ReportCounter(name string, tags map[string]string, value int64) {
+	o.mu.Lock()
+	c, ok := o.counters[name]
+	if !ok {
+		var err error
+		c, err = o.meter.SyncInt64().Counter(name)
+		if err != nil {
+			o.mu.Unlock()
+			o.log.Error("otlp counter", zap.String("name", name), zap.Error(err))
+			return
+		}
+
+		o.counters[name] = c
+	}
+	o.mu.Unlock()
+
+	c.Add(context.Background(), value, attributes(tags)...)
+}
+
+// ReportGauge stores the gauge value, it is observed during the next collection.
+func (o *OTLP) ReportGauge(name string, tags map[string]string, value float64) {
+	attrs := attributes(tags)
+	set := attribute.NewSet(attrs...)
+	key := set.Equivalent()
+
+	o.mu.Lock()
+	values, ok := o.gauges[name]
+	if !ok {
+		values = make(map[attribute.Distinct]*gaugeValue)
+		o.gauges[name] = values
+	}
+
+	values[key] = &gaugeValue{attrs: attrs, value: value}
+	o.mu.Unlock()
+
+	if ok {
+		return
+	}
+
+	// the collection holds the meter lock while calling callbacks and the callback takes o.mu, so the callback is
+	// registered outside o.mu
+	g, err := o.meter.AsyncFloat64().Gauge(name)
+	if err != nil {
+		o.log.Error("otlp gauge", zap.String("name", name), zap.Error(err))
+		return
+	}
+
+	err = o.meter.RegisterCallback([]instrument.Asynchronous{g}, func(ctx context.Context) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		for _, v := range values {
+			g.Observe(ctx, v.value, v.attrs...)
+		}
+	})
+	if err != nil {
+		o.log.Error("otlp gauge callback", zap.String("name", name), zap.Error(err))
+	}
+}
+
+// ReportTimer records the interval in seconds.
+func (o *OTLP) ReportTimer(name string, tags map[string]string, interval time.Duration) {
+	h := o.histogram(name, unit.Unit("s"))
+	if h == nil {
+		return
+	}
+
+	h.Record(context.Background(), interval.Seconds(), attributes(tags)...)
+}
+
+// ReportHistogramValueSamples records the bucket upper bound for every sample. tally reports histograms as the number
+// of samples per bucket, the sampled values are not known.
+func (o *OTLP) ReportHistogramValueSamples(name string, tags map[string]string, _ tally.Buckets, _, bucketUpperBound float64, samples int64) {
+	h := o.histogram(name, unit.Dimensionless)
+	if h == nil {
+		return
+	}
+
+	attrs := attributes(tags)
+	for i := int64(0); i < samples; i++ {
+		h.Record(context.Background(), bucketUpperBound, attrs...)
+	}
+}
+
+// ReportHistogramDurationSamples records the bucket upper bound in seconds for every sample.
+func (o *OTLP) ReportHistogramDurationSamples(name string, tags map[string]string, _ tally.Buckets, _, bucketUpperBound time.Duration, samples int64) {
+	h := o.histogram(name, unit.Unit("s"))
+	if h == nil {
+		return
+	}
+
+	attrs := attributes(tags)
+	for i := int64(0); i < samples; i++ {
+		h.Record(context.Background(), bucketUpperBound.Seconds(), attrs...)
+	}
+}
+
+func (o *OTLP) Capabilities() tally.Capabilities {
+	return o
+}
+
+func (o *OTLP) Reporting() bool {
+	return true
+}
+
+func (o *OTLP) Tagging() bool {
+	return true
+}
+
+// Flush is no-op, metrics are pushed by the controller every collect period.
+func (o *OTLP) Flush() {}
+
+// Close exports the collected metrics and stops the exporter.
+func (o *OTLP) Close() error {
+	err := o.ctrl.Stop(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return o.exporter.Shutdown(context.Background())
+}
+
+func (o *OTLP) histogram(name string, u unit.Unit) syncfloat64.Histogram {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	h, ok := o.histograms[name]
+	if !ok {
+		var err error
+		h, err = o.meter.SyncFloat64().Histogram(name, instrument.WithUnit(u))
+		if err != nil {
+			o.log.Error("otlp histogram", zap.String("name", name), zap.Error(err))
+			return nil
+		}
+
+		o.histograms[name] = h
+	}
+
+	return h
+}
+
+func attributes(tags map[string]string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	return attrs
+}
@@ -0,0 +1,120 @@
+package reporter
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	"go.opentelemetry.io/otel/sdk/metric/export"
+	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
+	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"go.uber.org/zap"
+)
+
+func testOTLP() (*OTLP, *controller.Controller) {
+	ctrl := controller.New(
+		processor.NewFactory(simple.NewWithHistogramDistribution(), aggregation.CumulativeTemporalitySelector()),
+		controller.WithCollectPeriod(0),
+	)
+
+	return &OTLP{
+		log:        zap.NewNop(),
+		ctrl:       ctrl,
+		meter:      ctrl.Meter(instrumentationName),
+		counters:   make(map[string]syncint64.Counter),
+		histograms: make(map[string]syncfloat64.Histogram),
+		gauges:     make(map[string]map[attribute.Distinct]*gaugeValue),
+	}, ctrl
+}
+
+// gauges returns the last values by the gauge name and the tag value.
+func gauges(t *testing.T, ctrl *controller.Controller) map[string]float64 {
+	values := make(map[string]float64)
+	err := ctrl.ForEach(func(_ instrumentation.Library, r export.Reader) error {
+		return r.ForEach(aggregation.CumulativeTemporalitySelector(), func(rec export.Record) error {
+			lv, ok := rec.Aggregation().(aggregation.LastValue)
+			if !ok {
+				return nil
+			}
+
+			v, _, err := lv.LastValue()
+			if err != nil {
+				return err
+			}
+
+			tag, _ := rec.Attributes().Value("tag")
+			values[rec.Descriptor().Name()+"/"+tag.AsString()] = v.AsFloat64()
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	return values
+}
+
+func Test_OTLPGauge(t *testing.T) {
+	o, ctrl := testOTLP()
+
+	o.ReportGauge("gauge", map[string]string{"tag": "a"}, 1)
+	o.ReportGauge("gauge", map[string]string{"tag": "b"}, 2)
+	o.ReportGauge("gauge", map[string]string{"tag": "a"}, 3)
+
+	require.NoError(t, ctrl.Collect(context.Background()))
+	assert.Equal(t, map[string]float64{"gauge/a": 3, "gauge/b": 2}, gauges(t, ctrl))
+}
+
+// new gauges are registered while the collection calls the callbacks of registered ones
+func Test_OTLPGaugeCollect(t *testing.T) {
+	o, ctrl := testOTLP()
+	o.ReportGauge("gauge", map[string]string{"tag": "a"}, 1)
+
+	stop := make(chan struct{})
+	collected := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = ctrl.Collect(context.Background())
+				if i == 0 {
+					close(collected)
+				}
+			}
+		}
+	}()
+
+	<-collected
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			o.ReportGauge("gauge_"+strconv.Itoa(i), map[string]string{"tag": "a"}, float64(i))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 10):
+		t.Fatal("gauges are not reported")
+	}
+
+	close(stop)
+	wg.Wait()
+
+	require.NoError(t, ctrl.Collect(context.Background()))
+	assert.Len(t, gauges(t, ctrl), 1001)
+}
@@ -0,0 +1,91 @@
+package reporter
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uber-go/tally/v4"
+)
+
+// Statsd is the tally reporter which encodes tags into the metric name, statsd does not support tags. Tags are
+// appended sorted by the key as the name.key.value segments.
+type Statsd struct {
+	r tally.StatsReporter
+}
+
+// NewStatsd wraps the statsd reporter.
+func NewStatsd(r tally.StatsReporter) *Statsd {
+	return &Statsd{r: r}
+}
+
+func (s *Statsd) ReportCounter(name string, tags map[string]string, value int64) {
+	s.r.ReportCounter(taggedName(name, tags), nil, value)
+}
+
+func (s *Statsd) ReportGauge(name string, tags map[string]string, value float64) {
+	s.r.ReportGauge(taggedName(name, tags), nil, value)
+}
+
+func (s *Statsd) ReportTimer(name string, tags map[string]string, interval time.Duration) {
+	s.r.ReportTimer(taggedName(name, tags), nil, interval)
+}
+
+func (s *Statsd) ReportHistogramValueSamples(name string, tags map[string]string, buckets tally.Buckets, bucketLowerBound, bucketUpperBound float64, samples int64) {
+	s.r.ReportHistogramValueSamples(taggedName(name, tags), nil, buckets, bucketLowerBound, bucketUpperBound, samples)
+}
+
+func (s *Statsd) ReportHistogramDurationSamples(name string, tags map[string]string, buckets tally.Buckets, bucketLowerBound, bucketUpperBound time.Duration, samples int64) {
+	s.r.ReportHistogramDurationSamples(taggedName(name, tags), nil, buckets, bucketLowerBound, bucketUpperBound, samples)
+}
+
+func (s *Statsd) Capabilities() tally.Capabilities {
+	return s
+}
+
+func (s *Statsd) Reporting() bool {
+	return true
+}
+
+func (s *Statsd) Tagging() bool {
+	return true
+}
+
+func (s *Statsd) Flush() {
+	s.r.Flush()
+}
+
+func taggedName(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sb := strings.Builder{}
+	sb.WriteString(name)
+	for i := 0; i < len(keys); i++ {
+		sb.WriteByte('.')
+		sb.WriteString(segment(keys[i]))
+		sb.WriteByte('.')
+		sb.WriteString(segment(tags[keys[i]]))
+	}
+
+	return sb.String()
+}
+
+// segment replaces characters having a special meaning in the statsd protocol or in the metric path.
+func segment(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ':', '|', '@', '#', ',', ' ':
+			return '_'
+		}
+
+		return r
+	}, s)
+}
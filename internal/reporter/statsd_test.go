@@ -0,0 +1,15 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TaggedName(t *testing.T) {
+	assert.Equal(t, "rr_activity_calls", taggedName("rr_activity_calls", nil))
+	assert.Equal(t,
+		"rr_activity_calls.activity_type.Greet.task_queue.default_queue",
+		taggedName("rr_activity_calls", map[string]string{"task_queue": "default.queue", "activity_type": "Greet"}),
+	)
+}
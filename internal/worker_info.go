@@ -11,6 +11,9 @@ type WorkerInfo struct {
 	// TaskQueue assigned to the worker.
 	TaskQueue string `json:"taskQueue"`
 
+	// Namespace of the task queue, optional.
+	Namespace string `json:"namespace,omitempty"`
+
 	// Options describe worker options.
 	Options worker.Options `json:"options,omitempty"`
 
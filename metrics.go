@@ -4,11 +4,15 @@ import (
 	"io"
 	"time"
 
+	"github.com/cactus/go-statsd-client/statsd"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/roadrunner-server/api/v2/plugins/informer"
+	"github.com/roadrunner-server/errors"
 	"github.com/roadrunner-server/sdk/v2/metrics"
+	"github.com/temporalio/roadrunner-temporal/internal/reporter"
 	"github.com/uber-go/tally/v4"
 	"github.com/uber-go/tally/v4/prometheus"
+	tallyStatsd "github.com/uber-go/tally/v4/statsd"
 	"go.uber.org/zap"
 )
 
@@ -36,7 +40,30 @@ var (
 	}
 )
 
-func newPrometheusScope(c prometheus.Configuration, prefix string, log *zap.Logger) (tally.Scope, io.Closer, error) {
+// newScope creates the tally root scope for the configured metrics driver.
+func (p *Plugin) newScope() (tally.Scope, io.Closer, error) {
+	const op = errors.Op("temporal_metrics_scope")
+
+	cfg := p.config.Metrics
+
+	switch cfg.Driver {
+	case MetricsDriverPrometheus:
+		return newPrometheusScope(prometheus.Configuration{
+			ListenAddress: cfg.Address,
+			TimerType:     cfg.Type,
+		}, cfg.Prefix, cfg.Interval, p.log)
+	case MetricsDriverRR:
+		return newRRScope(p.tallyCollector, cfg.Type, cfg.Prefix, cfg.Interval, p.log)
+	case MetricsDriverStatsd:
+		return newStatsdScope(cfg.Address, cfg.Prefix, cfg.Interval)
+	case MetricsDriverOTLP:
+		return newOTLPScope(cfg.Address, cfg.Insecure, cfg.Prefix, cfg.Interval, p.log)
+	default:
+		return nil, nil, errors.E(op, errors.Errorf("unknown metrics driver: %s, should be one of: %s, %s, %s, %s", cfg.Driver, MetricsDriverPrometheus, MetricsDriverRR, MetricsDriverStatsd, MetricsDriverOTLP))
+	}
+}
+
+func newPrometheusScope(c prometheus.Configuration, prefix string, interval time.Duration, log *zap.Logger) (tally.Scope, io.Closer, error) {
 	reporter, err := c.NewReporter(
 		prometheus.ConfigurationOptions{
 			Registry: prom.NewRegistry(),
@@ -54,15 +81,96 @@ func newPrometheusScope(c prometheus.Configuration, prefix string, log *zap.Logg
 		SanitizeOptions: &sanitizeOptions,
 		Prefix:          prefix,
 	}
-	scope, closer := tally.NewRootScope(scopeOpts, time.Second)
+	scope, closer := tally.NewRootScope(scopeOpts, interval)
+
+	return scope, closer, nil
+}
+
+// newRRScope registers tally metrics in the collector, which is exported via the RoadRunner metrics plugin
+func newRRScope(collector prom.Registerer, timerType, prefix string, interval time.Duration, log *zap.Logger) (tally.Scope, io.Closer, error) {
+	opts := prometheus.Options{
+		Registerer: collector,
+		OnRegisterError: func(err error) {
+			log.Error("prometheus registry", zap.Error(err))
+		},
+	}
+
+	if timerType == "histogram" {
+		opts.DefaultTimerType = prometheus.HistogramTimerType
+	}
+
+	scopeOpts := tally.ScopeOptions{
+		CachedReporter:  prometheus.NewReporter(opts),
+		Separator:       prometheus.DefaultSeparator,
+		SanitizeOptions: &sanitizeOptions,
+		Prefix:          prefix,
+	}
+	scope, closer := tally.NewRootScope(scopeOpts, interval)
+
+	return scope, closer, nil
+}
+
+// newStatsdScope pushes metrics to the statsd server, statsd does not support tags, so they are appended to the name
+func newStatsdScope(address, prefix string, interval time.Duration) (tally.Scope, io.Closer, error) {
+	statter, err := statsd.NewClientWithConfig(&statsd.ClientConfig{
+		Address: address,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopeOpts := tally.ScopeOptions{
+		Reporter:  reporter.NewStatsd(tallyStatsd.NewReporter(statter, tallyStatsd.Options{})),
+		Separator: tally.DefaultSeparator,
+		Prefix:    prefix,
+	}
+	scope, closer := tally.NewRootScope(scopeOpts, interval)
+
+	return scope, &closers{closer, statter}, nil
+}
+
+// newOTLPScope pushes metrics to the OpenTelemetry collector, names are sanitized the same way as for the prometheus
+func newOTLPScope(address string, insecure bool, prefix string, interval time.Duration, log *zap.Logger) (tally.Scope, io.Closer, error) {
+	otlp, err := reporter.NewOTLP(address, insecure, interval, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopeOpts := tally.ScopeOptions{
+		Reporter:        otlp,
+		Separator:       prometheus.DefaultSeparator,
+		SanitizeOptions: &sanitizeOptions,
+		Prefix:          prefix,
+	}
+	// the root scope closes the reporter
+	scope, closer := tally.NewRootScope(scopeOpts, interval)
 
 	return scope, closer, nil
 }
 
+// closers closes all underlying closers in order
+type closers []io.Closer
+
+func (c closers) Close() error {
+	for i := 0; i < len(c); i++ {
+		err := c[i].Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *Plugin) MetricsCollector() []prom.Collector {
 	// p - implements Exporter interface (workers)
 	// other - request duration and count
-	return []prom.Collector{p.statsExporter}
+	collectors := append([]prom.Collector{p.statsExporter, p.clusterSwitches}, p.metrics.Collectors()...)
+	if p.config.Metrics != nil && p.config.Metrics.Driver == MetricsDriverRR {
+		collectors = append(collectors, p.tallyCollector)
+	}
+
+	return collectors
 }
 
 const (
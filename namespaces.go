@@ -0,0 +1,51 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"github.com/temporalio/roadrunner-temporal/internal"
+	temporalClient "go.temporal.io/sdk/client"
+)
+
+// bindNamespaces sets the namespace for every task queue: the namespace sent by the worker or the namespaces
+// configuration. Task queues without the namespace use the default namespace of the cluster.
+func (p *Plugin) bindNamespaces(wi []*internal.WorkerInfo) {
+	bindings := make(map[string]string)
+	for i := 0; i < len(p.config.Namespaces); i++ {
+		for j := 0; j < len(p.config.Namespaces[i].TaskQueues); j++ {
+			bindings[p.config.Namespaces[i].TaskQueues[j]] = p.config.Namespaces[i].Name
+		}
+	}
+
+	for i := 0; i < len(wi); i++ {
+		if wi[i].Namespace != "" {
+			continue
+		}
+
+		if ns, ok := bindings[wi[i].TaskQueue]; ok {
+			wi[i].Namespace = ns
+		}
+	}
+}
+
+// namespaceName returns the namespace name, the empty namespace is the default namespace of the primary cluster.
+func (p *Plugin) namespaceName(namespace string) string {
+	if namespace == "" {
+		return p.config.Namespace
+	}
+
+	return namespace
+}
+
+// initClients creates clients of the cluster for all namespaces used by the workers.
+func (p *Plugin) initClients(c *cluster) (map[string]temporalClient.Client, error) {
+	clients := make(map[string]temporalClient.Client, 1)
+	for i := 0; i < len(p.workerInfo); i++ {
+		tc, err := c.namespaceClient(p.workerInfo[i].Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		clients[p.workerInfo[i].Namespace] = tc
+	}
+
+	return clients, nil
+}
@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/roadrunner-server/api/v2/event_bus"
 	"github.com/roadrunner-server/api/v2/plugins/config"
 	"github.com/roadrunner-server/api/v2/plugins/server"
@@ -23,7 +24,7 @@ import (
 	"github.com/temporalio/roadrunner-temporal/internal"
 	"github.com/temporalio/roadrunner-temporal/internal/codec/proto"
 	"github.com/temporalio/roadrunner-temporal/internal/logger"
-	"github.com/uber-go/tally/v4/prometheus"
+	"github.com/temporalio/roadrunner-temporal/internal/reporter"
 	temporalClient "go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/contrib/tally"
 	"go.temporal.io/sdk/converter"
@@ -48,33 +49,53 @@ const (
 type Plugin struct {
 	mu sync.RWMutex
 
-	server        server.Server
-	log           *zap.Logger
-	config        *Config
-	tallyCloser   io.Closer
-	statsExporter *metrics.StatsExporter
+	server      server.Server
+	log         *zap.Logger
+	config      *Config
+	tallyCloser io.Closer
+	// tally metrics registry for the rr metrics driver
+	tallyCollector *reporter.Collector
+	statsExporter  *metrics.StatsExporter
+	metrics        *aggregatedpool.Metrics
 
 	client        temporalClient.Client
 	dataConverter converter.DataConverter
+	// temporal clusters, the first one is the primary
+	clusters []*cluster
+	// index of the cluster used by the RPC client calls
+	activeCluster   uint32
+	clusterSwitches *prom.CounterVec
 
 	actP rrPool.Pool
 	wfP  rrPool.Pool
+	// dedicated local activities pool, nil unless configured
+	laP rrPool.Pool
 
 	rrVersion     string
 	rrActivityDef *aggregatedpool.Activity
 	rrWorkflowDef *aggregatedpool.Workflow
+	workerInfo    []*internal.WorkerInfo
 	workflows     map[string]*internal.WorkflowInfo
 	activities    map[string]*internal.ActivityInfo
 	codec         *proto.Codec
 
 	eventBus event_bus.EventBus
-	id       string
-	events   chan event_bus.Event
-	stopCh   chan struct{}
+	// temporal lifecycle events
+	lifecycle *aggregatedpool.Events
+	id        string
+	events    chan event_bus.Event
+	stopCh    chan struct{}
 
 	seqID        uint64
 	workers      []worker.Worker
 	graceTimeout time.Duration
+
+	// 1 when temporal workers are started
+	started uint32
+	// 1 while the Reset is in progress
+	resetting uint32
+	// 1 while the temporal server is reachable
+	connected uint32
 }
 
 func (p *Plugin) Init(cfg config.Configurer, log *zap.Logger, server server.Server) error {
@@ -91,6 +112,26 @@ func (p *Plugin) Init(cfg config.Configurer, log *zap.Logger, server server.Serv
 
 	p.config.InitDefault()
 
+	err = validateWorkerOptions(p.config.Workers)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = validateClusters(p.config)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	switch p.config.LocalActivities.RunOn {
+	case LocalActivitiesWorkflow, LocalActivitiesActivity, LocalActivitiesDedicated:
+	default:
+		return errors.E(op, errors.Errorf("unknown local activities pool: %s, should be one of: %s, %s, %s", p.config.LocalActivities.RunOn, LocalActivitiesWorkflow, LocalActivitiesActivity, LocalActivitiesDedicated))
+	}
+
+	if p.config.LocalActivities.RunOn == LocalActivitiesDedicated && p.config.LocalActivities.Pool.Command == "" {
+		return errors.E(op, errors.Str("local activities dedicated pool command is not set"))
+	}
+
 	p.dataConverter = data_converter.NewDataConverter(converter.GetDefaultDataConverter())
 	p.log = &zap.Logger{}
 	*p.log = *log
@@ -102,8 +143,12 @@ func (p *Plugin) Init(cfg config.Configurer, log *zap.Logger, server server.Serv
 	// events
 	p.events = make(chan event_bus.Event, 1)
 	p.eventBus, p.id = events.Bus()
+	p.lifecycle = aggregatedpool.NewEvents(p.eventBus, PluginName, p.log)
 	p.stopCh = make(chan struct{}, 1)
 	p.statsExporter = newStatsExporter(p)
+	p.metrics = aggregatedpool.NewMetrics(p.config.Metrics != nil && p.config.Metrics.Driver != MetricsDriverRR)
+	p.clusterSwitches = newClusterSwitches()
+	p.tallyCollector = reporter.NewCollector()
 
 	return nil
 }
@@ -126,10 +171,7 @@ func (p *Plugin) Serve() chan error {
 	}
 
 	if p.config.Metrics != nil {
-		ms, cl, errPs := newPrometheusScope(prometheus.Configuration{
-			ListenAddress: p.config.Metrics.Address,
-			TimerType:     p.config.Metrics.Type,
-		}, p.config.Metrics.Prefix, p.log)
+		ms, cl, errPs := p.newScope()
 		if errPs != nil {
 			errCh <- errors.E(op, errPs)
 			return errCh
@@ -139,14 +181,22 @@ func (p *Plugin) Serve() chan error {
 		p.tallyCloser = cl
 	}
 
-	p.client, err = temporalClient.Dial(opts)
+	err = p.initClusters(opts)
 	if err != nil {
 		errCh <- errors.E(op, err)
 		return errCh
 	}
 
-	p.log.Info("connected to temporal server", zap.String("address", p.config.Address))
-	p.codec = proto.NewCodec(p.log, p.dataConverter)
+	// primary cluster options
+	opts = p.clusters[0].opts
+
+	pl, err := p.payloadLogger()
+	if err != nil {
+		errCh <- errors.E(op, err)
+		return errCh
+	}
+
+	p.codec = proto.NewCodec(p.log, p.dataConverter, pl)
 
 	err = p.initPool()
 	if err != nil {
@@ -154,6 +204,38 @@ func (p *Plugin) Serve() chan error {
 		return errCh
 	}
 
+	switch {
+	case p.config.Dial == nil:
+		p.client, err = temporalClient.Dial(opts)
+		if err != nil {
+			errCh <- errors.E(op, err)
+			return errCh
+		}
+
+		p.clusters[0].setClient(p.client)
+
+		p.log.Info("connected to temporal server", zap.String("address", p.config.Address))
+		atomic.StoreUint32(&p.connected, 1)
+
+		err = p.initWorkers()
+		if err != nil {
+			errCh <- errors.E(op, err)
+			return errCh
+		}
+	case p.config.Dial.Lazy:
+		p.client, err = temporalClient.NewLazyClient(opts)
+		if err != nil {
+			errCh <- errors.E(op, err)
+			return errCh
+		}
+
+		p.clusters[0].setClient(p.client)
+
+		go p.connect(opts, errCh)
+	default:
+		go p.connect(opts, errCh)
+	}
+
 	err = p.eventBus.SubscribeP(p.id, fmt.Sprintf("*.%s", events.EventWorkerStopped.String()), p.events)
 	if err != nil {
 		errCh <- errors.E(op, err)
@@ -176,6 +258,9 @@ func (p *Plugin) Serve() chan error {
 		}
 	}()
 
+	go p.watchConnection()
+	go p.watchClusters()
+
 	return errCh
 }
 
@@ -185,9 +270,10 @@ func (p *Plugin) Stop() error {
 
 	// stop events
 	p.eventBus.Unsubscribe(p.id)
-	p.stopCh <- struct{}{}
+	close(p.stopCh)
 	p.eventBus = nil
 
+	atomic.StoreUint32(&p.started, 0)
 	for i := 0; i < len(p.workers); i++ {
 		p.workers[i].Stop()
 	}
@@ -199,7 +285,14 @@ func (p *Plugin) Stop() error {
 		}
 	}
 
+	for i := 0; i < len(p.clusters); i++ {
+		// the primary default namespace client is the plugin client
+		p.clusters[i].close(i > 0)
+	}
+
 	if p.client != nil {
+		// do not report the connection loss on stop
+		atomic.StoreUint32(&p.connected, 0)
 		p.client.Close()
 	}
 
@@ -210,6 +303,9 @@ func (p *Plugin) Workers() []*process.State {
 	p.mu.RLock()
 	wfPw := p.wfP.Workers()
 	actPw := p.actP.Workers()
+	if p.laP != nil {
+		actPw = append(actPw, p.laP.Workers()...)
+	}
 	p.mu.RUnlock()
 
 	states := make([]*process.State, 0, len(wfPw)+len(actPw))
@@ -241,9 +337,14 @@ func (p *Plugin) Workers() []*process.State {
 
 func (p *Plugin) Reset() error {
 	const op = errors.Op("temporal_reset")
+	atomic.StoreUint32(&p.resetting, 1)
+	defer atomic.StoreUint32(&p.resetting, 0)
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	started := atomic.SwapUint32(&p.started, 0) == 1
+
 	p.log.Info("reset signal received, resetting activity and workflow worker pools")
 
 	// stop temporal workers
@@ -266,6 +367,14 @@ func (p *Plugin) Reset() error {
 	}
 	p.log.Info("activity pool restarted")
 
+	if p.laP != nil {
+		errLp := p.laP.Reset(context.Background())
+		if errLp != nil {
+			return errors.E(op, errLp)
+		}
+		p.log.Info("local activities pool restarted")
+	}
+
 	// get worker info
 	wi := make([]*internal.WorkerInfo, 0, 5)
 	err := aggregatedpool.GetWorkerInfo(p.codec, p.wfP, p.rrVersion, &wi)
@@ -273,15 +382,22 @@ func (p *Plugin) Reset() error {
 		return err
 	}
 
-	// based on the worker info -> initialize workers
-	p.workers, err = aggregatedpool.InitWorkers(p.rrWorkflowDef, p.rrActivityDef, wi, p.log, p.client, p.graceTimeout)
+	p.enableSessions(wi)
+	p.bindNamespaces(wi)
+	p.applyWorkerOptions(wi)
+
+	// fail before any poller is started
+	err = aggregatedpool.ValidateWorkerInfo(wi)
 	if err != nil {
 		return err
 	}
 
-	// start workers
-	for i := 0; i < len(p.workers); i++ {
-		err = p.workers[i].Start()
+	p.workerInfo = wi
+
+	// workers are started by the connect loop when the temporal server was not reachable yet
+	if started {
+		// based on the worker info -> initialize workers
+		err = p.initWorkers()
 		if err != nil {
 			return err
 		}
@@ -290,6 +406,8 @@ func (p *Plugin) Reset() error {
 	p.activities = aggregatedpool.GrabActivities(wi)
 	p.workflows = aggregatedpool.GrabWorkflows(wi)
 
+	p.lifecycle.Send(aggregatedpool.EventPoolReset, "workflow and activity pools restarted")
+
 	return nil
 }
 
@@ -298,7 +416,7 @@ func (p *Plugin) Name() string {
 }
 
 func (p *Plugin) RPC() interface{} {
-	return &rpc{srv: p, client: p.client}
+	return &rpc{srv: p}
 }
 
 func (p *Plugin) SedID() uint64 {
@@ -314,7 +432,8 @@ func (p *Plugin) initPool() error {
 		return err
 	}
 
-	p.rrActivityDef = aggregatedpool.NewActivityDefinition(p.codec, ap, p.log, p.dataConverter, p.client, p.graceTimeout)
+	slots := aggregatedpool.NewWorkerSlots(ap)
+	p.rrActivityDef = aggregatedpool.NewActivityDefinition(p.codec, ap, p.log, p.dataConverter, p.graceTimeout, p.config.ActivityDeadlineGrace, p.autoHeartbeats(), p.activityLimiter(), slots, p.metrics, p.lifecycle)
 
 	// ---------- WORKFLOW POOL -------------
 	wp, err := p.server.NewWorkerPool(
@@ -334,7 +453,26 @@ func (p *Plugin) initPool() error {
 		return err
 	}
 
-	p.rrWorkflowDef = aggregatedpool.NewWorkflowDefinition(p.codec, p.dataConverter, wp, p.log, p.SedID, p.client, p.graceTimeout)
+	// workflow tasks are bounded by the deadlock detection timeout unless configured
+	var taskTimeout time.Duration
+	if p.config.WorkflowTask != nil {
+		taskTimeout = p.config.WorkflowTask.Timeout
+	}
+
+	p.rrWorkflowDef = aggregatedpool.NewWorkflowDefinition(p.codec, p.dataConverter, wp, p.log, p.SedID, p.graceTimeout, p.config.QueryTimeout, taskTimeout, p.metrics, p.lifecycle, p.config.EnableLoggingInReplay)
+
+	switch p.config.LocalActivities.RunOn {
+	case LocalActivitiesActivity:
+		p.rrWorkflowDef.SetLocalActivityPool(ap, p.config.ActivityDeadlineGrace, slots)
+	case LocalActivitiesDedicated:
+		lp, errL := p.server.NewWorkerPool(context.Background(), p.config.LocalActivities.Pool, map[string]string{RrMode: PluginName, RrCodec: RrCodecVal}, p.log)
+		if errL != nil {
+			return errL
+		}
+
+		p.rrWorkflowDef.SetLocalActivityPool(lp, p.config.ActivityDeadlineGrace, aggregatedpool.NewWorkerSlots(lp))
+		p.laP = lp
+	}
 
 	// get worker information
 	wi := make([]*internal.WorkerInfo, 0, 5)
@@ -343,11 +481,47 @@ func (p *Plugin) initPool() error {
 		return err
 	}
 
-	p.workers, err = aggregatedpool.InitWorkers(p.rrWorkflowDef, p.rrActivityDef, wi, p.log, p.client, p.graceTimeout)
+	p.enableSessions(wi)
+	p.bindNamespaces(wi)
+	p.applyWorkerOptions(wi)
+
+	// fail before any poller is started
+	err = aggregatedpool.ValidateWorkerInfo(wi)
 	if err != nil {
 		return err
 	}
 
+	p.workerInfo = wi
+
+	p.activities = aggregatedpool.GrabActivities(wi)
+	p.workflows = aggregatedpool.GrabWorkflows(wi)
+	p.actP = ap
+	p.wfP = wp
+
+	return nil
+}
+
+// initWorkers creates and starts temporal workers based on the worker info, should be called under the lock.
+func (p *Plugin) initWorkers() error {
+	clusters := p.polledClusters()
+	p.workers = make([]worker.Worker, 0, len(clusters))
+
+	for i := 0; i < len(clusters); i++ {
+		clients, err := p.initClients(clusters[i])
+		if err != nil {
+			return err
+		}
+
+		workers, err := aggregatedpool.InitWorkers(p.rrWorkflowDef, p.rrActivityDef, p.workerInfo, p.log, clients, clusters[i].namespace, p.graceTimeout)
+		if err != nil {
+			return err
+		}
+
+		p.workers = append(p.workers, workers...)
+	}
+
+	var err error
+
 	for i := 0; i < len(p.workers); i++ {
 		err = p.workers[i].Start()
 		if err != nil {
@@ -355,10 +529,65 @@ func (p *Plugin) initPool() error {
 		}
 	}
 
-	p.activities = aggregatedpool.GrabActivities(wi)
-	p.workflows = aggregatedpool.GrabWorkflows(wi)
-	p.actP = ap
-	p.wfP = wp
+	atomic.StoreUint32(&p.started, 1)
 
 	return nil
 }
+
+// autoHeartbeats returns the auto-heartbeat intervals by the activity type.
+func (p *Plugin) autoHeartbeats() map[string]time.Duration {
+	hb := make(map[string]time.Duration, len(p.config.AutoHeartbeat))
+	for i := 0; i < len(p.config.AutoHeartbeat); i++ {
+		hb[p.config.AutoHeartbeat[i].Activity] = p.config.AutoHeartbeat[i].Interval
+	}
+
+	return hb
+}
+
+// activityLimiter returns the limiter of the activities listed in the activity limits configuration.
+func (p *Plugin) activityLimiter() *aggregatedpool.ActivityLimiter {
+	lim := aggregatedpool.NewActivityLimiter()
+	for i := 0; i < len(p.config.ActivityLimits); i++ {
+		l := p.config.ActivityLimits[i]
+		lim.SetLimit(l.Activity, l.MaxConcurrent, l.Rate, l.Burst)
+	}
+
+	return lim
+}
+
+// enableSessions turns on the session worker for the task queues listed in the sessions configuration.
+func (p *Plugin) enableSessions(wi []*internal.WorkerInfo) {
+	for i := 0; i < len(wi); i++ {
+		taskQueue := wi[i].TaskQueue
+		if taskQueue == "" {
+			taskQueue = "default"
+		}
+
+		for j := 0; j < len(p.config.Sessions); j++ {
+			if p.config.Sessions[j].TaskQueue != taskQueue {
+				continue
+			}
+
+			wi[i].Options.EnableSessionWorker = true
+			if p.config.Sessions[j].MaxConcurrentSessionExecutionSize != 0 {
+				wi[i].Options.MaxConcurrentSessionExecutionSize = p.config.Sessions[j].MaxConcurrentSessionExecutionSize
+			}
+
+			p.log.Debug("session worker enabled", zap.String("taskqueue", taskQueue), zap.Int("max_sessions", wi[i].Options.MaxConcurrentSessionExecutionSize))
+		}
+	}
+}
+
+// payloadLogger creates the codec payload logger from the payload_logging configuration.
+func (p *Plugin) payloadLogger() (*proto.PayloadLogger, error) {
+	pl, err := proto.NewPayloadLogger(p.config.PayloadLogging.Mode, p.config.PayloadLogging.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(p.config.PayloadLogging.Redact); i++ {
+		pl.Redact(p.config.PayloadLogging.Redact[i], nil)
+	}
+
+	return pl, nil
+}
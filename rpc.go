@@ -1,10 +1,14 @@
 package roadrunner_temporal //nolint:revive,stylecheck
 
 import (
+	"context"
+	stderr "errors"
+
 	v1Proto "github.com/golang/protobuf/proto" //nolint:staticcheck,nolintlint
+	"github.com/temporalio/roadrunner-temporal/aggregatedpool"
 	commonpb "go.temporal.io/api/common/v1"
-	"go.temporal.io/sdk/activity"
-	"go.temporal.io/sdk/client"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -16,14 +20,15 @@ import (
 - the method has return type error.
 */
 type rpc struct {
-	srv    *Plugin
-	client client.Client
+	srv *Plugin
 }
 
 // RecordHeartbeatRequest sent by activity to record current state.
 type RecordHeartbeatRequest struct {
 	TaskToken []byte `json:"taskToken"`
 	Details   []byte `json:"details"`
+	// Namespace of the activity, used when the activity is not executed by the plugin, default namespace when empty.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // RecordHeartbeatResponse sent back to the worker to indicate that activity was canceled.
@@ -49,14 +54,12 @@ func (r *rpc) RecordActivityHeartbeat(in RecordHeartbeatRequest, out *RecordHear
 
 	// find running activity
 	r.srv.mu.RLock()
-	ctx, err := r.srv.rrActivityDef.GetActivityContext(in.TaskToken)
+	ctx, err := r.srv.rrActivityDef.RecordHeartbeat(in.TaskToken, details)
+	r.srv.mu.RUnlock()
 	if err != nil {
-		r.srv.mu.RUnlock()
-		return err
+		// the activity is not executed by this plugin (e.g. completed asynchronously), heartbeat via the client
+		return r.recordHeartbeat(in, details, out)
 	}
-	r.srv.mu.RUnlock()
-
-	activity.RecordHeartbeat(ctx, details)
 
 	select {
 	case <-ctx.Done():
@@ -68,6 +71,184 @@ func (r *rpc) RecordActivityHeartbeat(in RecordHeartbeatRequest, out *RecordHear
 	return nil
 }
 
+// RecordHeartbeatsRequest sent by activities to record heartbeats of multiple activities at once.
+type RecordHeartbeatsRequest struct {
+	Heartbeats []RecordHeartbeatRequest `json:"heartbeats"`
+}
+
+// RecordHeartbeatsResponse contains the cancellation state and the error of every heartbeat in the request order.
+type RecordHeartbeatsResponse struct {
+	Canceled []bool `json:"canceled"`
+	// Errors of the heartbeats which are not recorded, empty string when the heartbeat is recorded.
+	Errors []string `json:"errors"`
+}
+
+// RecordActivityHeartbeats records heartbeats for multiple activities under a single lock. Heartbeats of the same
+// activity are coalesced within the SDK throttle interval, the last details are always recorded. Every heartbeat is
+// processed, a failed heartbeat does not fail the others.
+func (r *rpc) RecordActivityHeartbeats(in RecordHeartbeatsRequest, out *RecordHeartbeatsResponse) error {
+	out.Canceled = make([]bool, len(in.Heartbeats))
+	out.Errors = make([]string, len(in.Heartbeats))
+
+	details := make([]*commonpb.Payloads, len(in.Heartbeats))
+	for i := 0; i < len(in.Heartbeats); i++ {
+		details[i] = &commonpb.Payloads{}
+		if len(in.Heartbeats[i].Details) == 0 {
+			continue
+		}
+
+		if err := proto.Unmarshal(in.Heartbeats[i].Details, v1Proto.MessageV2(details[i])); err != nil {
+			out.Errors[i] = err.Error()
+		}
+	}
+
+	// activities which are not executed by the plugin
+	external := make([]int, 0, 1)
+
+	r.srv.mu.RLock()
+	for i := 0; i < len(in.Heartbeats); i++ {
+		if out.Errors[i] != "" {
+			continue
+		}
+
+		ctx, err := r.srv.rrActivityDef.RecordHeartbeat(in.Heartbeats[i].TaskToken, details[i])
+		if err != nil {
+			external = append(external, i)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			out.Canceled[i] = true
+		default:
+		}
+	}
+	r.srv.mu.RUnlock()
+
+	for _, i := range external {
+		resp := RecordHeartbeatResponse{}
+		err := r.recordHeartbeat(in.Heartbeats[i], details[i], &resp)
+		if err != nil {
+			out.Errors[i] = err.Error()
+			continue
+		}
+
+		out.Canceled[i] = resp.Canceled
+	}
+
+	return nil
+}
+
+// recordHeartbeat records the heartbeat via the client of the cluster which issued the task. Clusters are tried
+// starting from the active one until the task token is found.
+func (r *rpc) recordHeartbeat(in RecordHeartbeatRequest, details *commonpb.Payloads, out *RecordHeartbeatResponse) error {
+	var lastErr error
+	for _, c := range r.srv.heartbeatClusters() {
+		tc, err := c.namespaceClient(in.Namespace)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		err = tc.RecordActivityHeartbeat(context.Background(), in.TaskToken, details)
+		if err == nil {
+			*out = RecordHeartbeatResponse{Canceled: false}
+			return nil
+		}
+
+		var canceled *temporal.CanceledError
+		if stderr.As(err, &canceled) {
+			*out = RecordHeartbeatResponse{Canceled: true}
+			return nil
+		}
+
+		if !unknownTask(err) {
+			return err
+		}
+
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// unknownTask reports whether the cluster does not know the task of the token.
+func unknownTask(err error) bool {
+	var notFound *serviceerror.NotFound
+	var nsNotFound *serviceerror.NamespaceNotFound
+	var nsNotActive *serviceerror.NamespaceNotActive
+	var invalid *serviceerror.InvalidArgument
+
+	return stderr.As(err, &notFound) || stderr.As(err, &nsNotFound) || stderr.As(err, &nsNotActive) || stderr.As(err, &invalid)
+}
+
+// RecordActivityMetricsRequest sent by activity to emit custom metrics.
+type RecordActivityMetricsRequest struct {
+	TaskToken []byte                         `json:"taskToken"`
+	Metrics   []*aggregatedpool.CustomMetric `json:"metrics"`
+}
+
+// RecordWorkflowMetricsRequest sent by workflow to emit custom metrics.
+type RecordWorkflowMetricsRequest struct {
+	RunID   string                         `json:"runId"`
+	Metrics []*aggregatedpool.CustomMetric `json:"metrics"`
+}
+
+// RecordActivityMetrics emits counters, gauges and timers via the metrics handler of the running activity.
+// Metrics inherit the activity namespace, task queue and type tags.
+func (r *rpc) RecordActivityMetrics(in RecordActivityMetricsRequest, out *bool) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	err := r.srv.rrActivityDef.RecordMetrics(in.TaskToken, in.Metrics)
+	if err != nil {
+		return err
+	}
+
+	*out = true
+
+	return nil
+}
+
+// RecordWorkflowMetrics emits counters, gauges and timers via the metrics handler of the cached workflow execution.
+// Metrics are not emitted while the workflow is replaying.
+func (r *rpc) RecordWorkflowMetrics(in RecordWorkflowMetricsRequest, out *bool) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	err := r.srv.rrWorkflowDef.RecordMetrics(in.RunID, in.Metrics)
+	if err != nil {
+		return err
+	}
+
+	*out = true
+
+	return nil
+}
+
+// ActivityLogRequest sent by activity to write the log entry.
+type ActivityLogRequest struct {
+	TaskToken []byte                 `json:"taskToken"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Context   map[string]interface{} `json:"context"`
+}
+
+// ActivityLog writes the activity log entry tagged with the activity and the parent workflow execution fields.
+func (r *rpc) ActivityLog(in ActivityLogRequest, out *bool) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	err := r.srv.rrActivityDef.Log(in.TaskToken, in.Level, in.Message, in.Context)
+	if err != nil {
+		return err
+	}
+
+	*out = true
+
+	return nil
+}
+
 func (r *rpc) GetActivityNames(_ bool, out *[]string) error {
 	r.srv.mu.RLock()
 	defer r.srv.mu.RUnlock()
@@ -87,3 +268,52 @@ func (r *rpc) GetWorkflowNames(_ bool, out *[]string) error {
 
 	return nil
 }
+
+// RunningActivities returns activities currently executed by the activity pool.
+func (r *rpc) RunningActivities(_ bool, out *[]*aggregatedpool.RunningActivity) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	*out = r.srv.rrActivityDef.RunningActivities()
+
+	return nil
+}
+
+// CachedWorkflows returns workflow executions kept in the sticky workflow cache.
+func (r *rpc) CachedWorkflows(_ bool, out *[]*aggregatedpool.CachedWorkflow) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	*out = r.srv.rrWorkflowDef.CachedWorkflows()
+
+	return nil
+}
+
+// GetNamespaces returns task queues polled by the plugin grouped by the namespace.
+func (r *rpc) GetNamespaces(_ bool, out *map[string][]string) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	namespaces := make(map[string][]string)
+	for i := 0; i < len(r.srv.workerInfo); i++ {
+		ns := r.srv.namespaceName(r.srv.workerInfo[i].Namespace)
+		namespaces[ns] = append(namespaces[ns], r.srv.workerInfo[i].TaskQueue)
+	}
+
+	*out = namespaces
+
+	return nil
+}
+
+// GetWorkerOptions returns effective worker options by the namespace and the task queue (PHP declared options merged
+// with the workers configuration).
+func (r *rpc) GetWorkerOptions(_ bool, out *[]*WorkerOptions) error {
+	r.srv.mu.RLock()
+	defer r.srv.mu.RUnlock()
+
+	for i := 0; i < len(r.srv.workerInfo); i++ {
+		*out = append(*out, effectiveWorkerOptions(r.srv.namespaceName(r.srv.workerInfo[i].Namespace), r.srv.workerInfo[i].TaskQueue, &r.srv.workerInfo[i].Options))
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/aggregatedpool"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+// every heartbeat of the batch is processed, failed heartbeats are reported by the entry
+func Test_RecordActivityHeartbeatsErrors(t *testing.T) {
+	r := &rpc{srv: &Plugin{
+		rrActivityDef: &aggregatedpool.Activity{},
+		clusters: []*cluster{{
+			log:     zap.NewNop(),
+			name:    "primary",
+			clients: make(map[string]temporalClient.Client),
+		}},
+	}}
+
+	out := &RecordHeartbeatsResponse{}
+	require.NoError(t, r.RecordActivityHeartbeats(RecordHeartbeatsRequest{Heartbeats: []RecordHeartbeatRequest{
+		{TaskToken: []byte("invalid details"), Details: []byte{0xff}},
+		{TaskToken: []byte("not running")},
+	}}, out))
+
+	assert.Equal(t, []bool{false, false}, out.Canceled)
+	require.Len(t, out.Errors, 2)
+	assert.NotEmpty(t, out.Errors[0])
+	assert.Contains(t, out.Errors[1], "temporal client is not connected")
+}
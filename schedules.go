@@ -0,0 +1,769 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/roadrunner-server/errors"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	schedulepb "go.temporal.io/api/schedule/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// overlap policies of the scheduled workflows
+var overlapPolicies = map[string]enumspb.ScheduleOverlapPolicy{ //nolint:gochecknoglobals
+	"":                enumspb.SCHEDULE_OVERLAP_POLICY_UNSPECIFIED,
+	"skip":            enumspb.SCHEDULE_OVERLAP_POLICY_SKIP,
+	"buffer_one":      enumspb.SCHEDULE_OVERLAP_POLICY_BUFFER_ONE,
+	"buffer_all":      enumspb.SCHEDULE_OVERLAP_POLICY_BUFFER_ALL,
+	"cancel_other":    enumspb.SCHEDULE_OVERLAP_POLICY_CANCEL_OTHER,
+	"terminate_other": enumspb.SCHEDULE_OVERLAP_POLICY_TERMINATE_OTHER,
+	"allow_all":       enumspb.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL,
+}
+
+// Duration of the schedule, the integer nanoseconds or the Go duration string ("1h30m") in requests, nanoseconds in
+// responses.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		var str string
+		err := json.Unmarshal(data, &str)
+		if err != nil {
+			return err
+		}
+
+		dur, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+
+		*d = Duration(dur)
+		return nil
+	}
+
+	var ns int64
+	err := json.Unmarshal(data, &ns)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(ns)
+	return nil
+}
+
+// Schedule of the workflow, see Duration for the durations format.
+type Schedule struct {
+	Spec     ScheduleSpec     `json:"spec"`
+	Action   ScheduleAction   `json:"action"`
+	Policies SchedulePolicies `json:"policies"`
+	State    ScheduleState    `json:"state"`
+}
+
+// ScheduleSpec describes the times the action is taken at.
+type ScheduleSpec struct {
+	Calendars        []ScheduleCalendar `json:"calendars,omitempty"`
+	Intervals        []ScheduleInterval `json:"intervals,omitempty"`
+	ExcludeCalendars []ScheduleCalendar `json:"excludeCalendars,omitempty"`
+	StartTime        *time.Time         `json:"startTime,omitempty"`
+	EndTime          *time.Time         `json:"endTime,omitempty"`
+	Jitter           Duration           `json:"jitter,omitempty"`
+	TimezoneName     string             `json:"timezoneName,omitempty"`
+}
+
+// ScheduleCalendar matches the calendar times, every field is a cron-like range expression.
+type ScheduleCalendar struct {
+	Second     string `json:"second,omitempty"`
+	Minute     string `json:"minute,omitempty"`
+	Hour       string `json:"hour,omitempty"`
+	DayOfMonth string `json:"dayOfMonth,omitempty"`
+	Month      string `json:"month,omitempty"`
+	Year       string `json:"year,omitempty"`
+	DayOfWeek  string `json:"dayOfWeek,omitempty"`
+}
+
+// ScheduleInterval matches every interval since the epoch shifted by the phase.
+type ScheduleInterval struct {
+	Interval Duration `json:"interval"`
+	Phase    Duration `json:"phase,omitempty"`
+}
+
+// ScheduleAction starts the workflow, arguments are encoded with the plugin data converter.
+type ScheduleAction struct {
+	WorkflowID               string        `json:"workflowId"`
+	WorkflowType             string        `json:"workflowType"`
+	TaskQueue                string        `json:"taskQueue"`
+	Args                     []interface{} `json:"args,omitempty"`
+	WorkflowExecutionTimeout Duration      `json:"workflowExecutionTimeout,omitempty"`
+	WorkflowRunTimeout       Duration      `json:"workflowRunTimeout,omitempty"`
+	WorkflowTaskTimeout      Duration      `json:"workflowTaskTimeout,omitempty"`
+}
+
+// SchedulePolicies of the schedule, the overlap policy is one of: skip (server default), buffer_one, buffer_all,
+// cancel_other, terminate_other, allow_all.
+type SchedulePolicies struct {
+	OverlapPolicy  string   `json:"overlapPolicy,omitempty"`
+	CatchupWindow  Duration `json:"catchupWindow,omitempty"`
+	PauseOnFailure bool     `json:"pauseOnFailure,omitempty"`
+}
+
+// ScheduleState of the schedule.
+type ScheduleState struct {
+	Notes            string `json:"notes,omitempty"`
+	Paused           bool   `json:"paused,omitempty"`
+	LimitedActions   bool   `json:"limitedActions,omitempty"`
+	RemainingActions int64  `json:"remainingActions,omitempty"`
+}
+
+// ScheduleInfo describes the schedule execution.
+type ScheduleInfo struct {
+	ActionCount          int64                  `json:"actionCount"`
+	MissedCatchupWindow  int64                  `json:"missedCatchupWindow"`
+	OverlapSkipped       int64                  `json:"overlapSkipped"`
+	RunningWorkflows     []ScheduledWorkflow    `json:"runningWorkflows,omitempty"`
+	RecentActions        []ScheduleActionResult `json:"recentActions,omitempty"`
+	FutureActionTimes    []time.Time            `json:"futureActionTimes,omitempty"`
+	CreateTime           *time.Time             `json:"createTime,omitempty"`
+	UpdateTime           *time.Time             `json:"updateTime,omitempty"`
+	InvalidScheduleError string                 `json:"invalidScheduleError,omitempty"`
+}
+
+// ScheduledWorkflow is the workflow execution started by the schedule.
+type ScheduledWorkflow struct {
+	WorkflowID string `json:"workflowId"`
+	RunID      string `json:"runId"`
+}
+
+// ScheduleActionResult is the action taken by the schedule.
+type ScheduleActionResult struct {
+	ScheduleTime *time.Time         `json:"scheduleTime,omitempty"`
+	ActualTime   *time.Time         `json:"actualTime,omitempty"`
+	Workflow     *ScheduledWorkflow `json:"workflow,omitempty"`
+}
+
+// ScheduleBackfill takes actions for the time range as if they were missed.
+type ScheduleBackfill struct {
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+	OverlapPolicy string    `json:"overlapPolicy,omitempty"`
+}
+
+// ScheduleListEntry is the schedule returned by the list, use DescribeSchedule for the details.
+type ScheduleListEntry struct {
+	ScheduleID       string                 `json:"scheduleId"`
+	Memo             map[string]interface{} `json:"memo,omitempty"`
+	SearchAttributes map[string]interface{} `json:"searchAttributes,omitempty"`
+}
+
+// CreateScheduleRequest creates the schedule, the default namespace is used when empty.
+type CreateScheduleRequest struct {
+	Namespace  string    `json:"namespace,omitempty"`
+	ScheduleID string    `json:"scheduleId"`
+	Schedule   *Schedule `json:"schedule"`
+}
+
+// CreateScheduleResponse contains the token for the conflict-free updates.
+type CreateScheduleResponse struct {
+	ConflictToken []byte `json:"conflictToken"`
+}
+
+// ScheduleRequest identifies the schedule.
+type ScheduleRequest struct {
+	Namespace  string `json:"namespace,omitempty"`
+	ScheduleID string `json:"scheduleId"`
+}
+
+// DescribeScheduleResponse describes the schedule.
+type DescribeScheduleResponse struct {
+	Schedule      *Schedule     `json:"schedule"`
+	Info          *ScheduleInfo `json:"info"`
+	ConflictToken []byte        `json:"conflictToken"`
+}
+
+// UpdateScheduleRequest replaces the schedule, the update fails if the conflict token is set and the schedule was
+// changed since it was obtained.
+type UpdateScheduleRequest struct {
+	Namespace     string    `json:"namespace,omitempty"`
+	ScheduleID    string    `json:"scheduleId"`
+	Schedule      *Schedule `json:"schedule"`
+	ConflictToken []byte    `json:"conflictToken,omitempty"`
+}
+
+// PauseScheduleRequest pauses or unpauses the schedule.
+type PauseScheduleRequest struct {
+	Namespace  string `json:"namespace,omitempty"`
+	ScheduleID string `json:"scheduleId"`
+	Pause      bool   `json:"pause"`
+	Note       string `json:"note,omitempty"`
+}
+
+// TriggerScheduleRequest takes the schedule action immediately.
+type TriggerScheduleRequest struct {
+	Namespace     string `json:"namespace,omitempty"`
+	ScheduleID    string `json:"scheduleId"`
+	OverlapPolicy string `json:"overlapPolicy,omitempty"`
+}
+
+// BackfillScheduleRequest takes the schedule actions for the time ranges.
+type BackfillScheduleRequest struct {
+	Namespace  string             `json:"namespace,omitempty"`
+	ScheduleID string             `json:"scheduleId"`
+	Backfills  []ScheduleBackfill `json:"backfills"`
+}
+
+// ListSchedulesRequest lists schedules of the namespace page by page.
+type ListSchedulesRequest struct {
+	Namespace     string `json:"namespace,omitempty"`
+	PageSize      int32  `json:"pageSize,omitempty"`
+	NextPageToken []byte `json:"nextPageToken,omitempty"`
+}
+
+// ListSchedulesResponse contains the schedules page, the next page token is empty on the last page.
+type ListSchedulesResponse struct {
+	Schedules     []*ScheduleListEntry `json:"schedules"`
+	NextPageToken []byte               `json:"nextPageToken,omitempty"`
+}
+
+// CreateSchedule creates the schedule.
+func (r *rpc) CreateSchedule(in CreateScheduleRequest, out *CreateScheduleResponse) error {
+	const op = errors.Op("temporal_rpc_create_schedule")
+
+	svc, namespace, err := r.scheduleService(in.Namespace)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	schedule, err := scheduleToProto(in.Schedule, r.srv.dataConverter)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp, err := svc.CreateSchedule(context.Background(), &workflowservice.CreateScheduleRequest{
+		Namespace:  namespace,
+		ScheduleId: in.ScheduleID,
+		Schedule:   schedule,
+		RequestId:  uuid.NewString(),
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	out.ConflictToken = resp.GetConflictToken()
+
+	return nil
+}
+
+// DescribeSchedule returns the schedule and its execution info.
+func (r *rpc) DescribeSchedule(in ScheduleRequest, out *DescribeScheduleResponse) error {
+	const op = errors.Op("temporal_rpc_describe_schedule")
+
+	svc, namespace, err := r.scheduleService(in.Namespace)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp, err := svc.DescribeSchedule(context.Background(), &workflowservice.DescribeScheduleRequest{
+		Namespace:  namespace,
+		ScheduleId: in.ScheduleID,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	schedule, err := scheduleFromProto(resp.GetSchedule(), r.srv.dataConverter)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = DescribeScheduleResponse{
+		Schedule:      schedule,
+		Info:          scheduleInfoFromProto(resp.GetInfo()),
+		ConflictToken: resp.GetConflictToken(),
+	}
+
+	return nil
+}
+
+// UpdateSchedule replaces the schedule.
+func (r *rpc) UpdateSchedule(in UpdateScheduleRequest, out *bool) error {
+	const op = errors.Op("temporal_rpc_update_schedule")
+
+	svc, namespace, err := r.scheduleService(in.Namespace)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	schedule, err := scheduleToProto(in.Schedule, r.srv.dataConverter)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	_, err = svc.UpdateSchedule(context.Background(), &workflowservice.UpdateScheduleRequest{
+		Namespace:     namespace,
+		ScheduleId:    in.ScheduleID,
+		Schedule:      schedule,
+		ConflictToken: in.ConflictToken,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = true
+
+	return nil
+}
+
+// PauseSchedule pauses or unpauses the schedule.
+func (r *rpc) PauseSchedule(in PauseScheduleRequest, out *bool) error {
+	const op = errors.Op("temporal_rpc_pause_schedule")
+
+	patch := &schedulepb.SchedulePatch{}
+	if in.Pause {
+		patch.Pause = in.Note
+		if patch.Pause == "" {
+			patch.Pause = "paused via RPC"
+		}
+	} else {
+		patch.Unpause = in.Note
+		if patch.Unpause == "" {
+			patch.Unpause = "unpaused via RPC"
+		}
+	}
+
+	err := r.patchSchedule(in.Namespace, in.ScheduleID, patch)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = true
+
+	return nil
+}
+
+// TriggerSchedule takes the schedule action immediately.
+func (r *rpc) TriggerSchedule(in TriggerScheduleRequest, out *bool) error {
+	const op = errors.Op("temporal_rpc_trigger_schedule")
+
+	policy, err := overlapPolicy(in.OverlapPolicy)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	err = r.patchSchedule(in.Namespace, in.ScheduleID, &schedulepb.SchedulePatch{
+		TriggerImmediately: &schedulepb.TriggerImmediatelyRequest{OverlapPolicy: policy},
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = true
+
+	return nil
+}
+
+// BackfillSchedule takes the schedule actions for the time ranges.
+func (r *rpc) BackfillSchedule(in BackfillScheduleRequest, out *bool) error {
+	const op = errors.Op("temporal_rpc_backfill_schedule")
+
+	backfills := make([]*schedulepb.BackfillRequest, 0, len(in.Backfills))
+	for i := 0; i < len(in.Backfills); i++ {
+		policy, err := overlapPolicy(in.Backfills[i].OverlapPolicy)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		backfills = append(backfills, &schedulepb.BackfillRequest{
+			StartTime:     timePtr(in.Backfills[i].StartTime),
+			EndTime:       timePtr(in.Backfills[i].EndTime),
+			OverlapPolicy: policy,
+		})
+	}
+
+	err := r.patchSchedule(in.Namespace, in.ScheduleID, &schedulepb.SchedulePatch{BackfillRequest: backfills})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = true
+
+	return nil
+}
+
+// ListSchedules returns the page of the namespace schedules.
+func (r *rpc) ListSchedules(in ListSchedulesRequest, out *ListSchedulesResponse) error {
+	const op = errors.Op("temporal_rpc_list_schedules")
+
+	svc, namespace, err := r.scheduleService(in.Namespace)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp, err := svc.ListSchedules(context.Background(), &workflowservice.ListSchedulesRequest{
+		Namespace:       namespace,
+		MaximumPageSize: in.PageSize,
+		NextPageToken:   in.NextPageToken,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	out.Schedules = make([]*ScheduleListEntry, 0, len(resp.GetSchedules()))
+	for _, s := range resp.GetSchedules() {
+		entry, errE := scheduleListEntryFromProto(s, r.srv.dataConverter)
+		if errE != nil {
+			return errors.E(op, errE)
+		}
+
+		out.Schedules = append(out.Schedules, entry)
+	}
+
+	out.NextPageToken = resp.GetNextPageToken()
+
+	return nil
+}
+
+// DeleteSchedule deletes the schedule, workflows started by the schedule are not affected.
+func (r *rpc) DeleteSchedule(in ScheduleRequest, out *bool) error {
+	const op = errors.Op("temporal_rpc_delete_schedule")
+
+	svc, namespace, err := r.scheduleService(in.Namespace)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	_, err = svc.DeleteSchedule(context.Background(), &workflowservice.DeleteScheduleRequest{
+		Namespace:  namespace,
+		ScheduleId: in.ScheduleID,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = true
+
+	return nil
+}
+
+func (r *rpc) patchSchedule(namespace, scheduleID string, patch *schedulepb.SchedulePatch) error {
+	svc, namespace, err := r.scheduleService(namespace)
+	if err != nil {
+		return err
+	}
+
+	_, err = svc.PatchSchedule(context.Background(), &workflowservice.PatchScheduleRequest{
+		Namespace:  namespace,
+		ScheduleId: scheduleID,
+		Patch:      patch,
+		RequestId:  uuid.NewString(),
+	})
+
+	return err
+}
+
+// scheduleService returns the workflow service of the active cluster and the namespace, the default namespace of the
+// cluster when empty.
+func (r *rpc) scheduleService(namespace string) (workflowservice.WorkflowServiceClient, string, error) {
+	tc, err := r.srv.rpcClient(namespace)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if namespace == "" {
+		namespace = r.srv.rpcNamespace()
+	}
+
+	return tc.WorkflowService(), namespace, nil
+}
+
+func overlapPolicy(name string) (enumspb.ScheduleOverlapPolicy, error) {
+	policy, ok := overlapPolicies[name]
+	if !ok {
+		return 0, errors.Errorf("unknown overlap policy: %s", name)
+	}
+
+	return policy, nil
+}
+
+func overlapPolicyName(policy enumspb.ScheduleOverlapPolicy) string {
+	for name, p := range overlapPolicies {
+		if p == policy {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func scheduleToProto(s *Schedule, dc converter.DataConverter) (*schedulepb.Schedule, error) {
+	if s == nil {
+		return nil, errors.Str("schedule should not be empty")
+	}
+
+	policy, err := overlapPolicy(s.Policies.OverlapPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	var input *commonpb.Payloads
+	if len(s.Action.Args) != 0 {
+		input, err = dc.ToPayloads(s.Action.Args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	spec := &schedulepb.ScheduleSpec{
+		Calendar:        calendarsToProto(s.Spec.Calendars),
+		ExcludeCalendar: calendarsToProto(s.Spec.ExcludeCalendars),
+		StartTime:       s.Spec.StartTime,
+		EndTime:         s.Spec.EndTime,
+		Jitter:          durationPtr(s.Spec.Jitter),
+		TimezoneName:    s.Spec.TimezoneName,
+	}
+
+	for i := 0; i < len(s.Spec.Intervals); i++ {
+		spec.Interval = append(spec.Interval, &schedulepb.IntervalSpec{
+			Interval: durationPtr(s.Spec.Intervals[i].Interval),
+			Phase:    durationPtr(s.Spec.Intervals[i].Phase),
+		})
+	}
+
+	return &schedulepb.Schedule{
+		Spec: spec,
+		Action: &schedulepb.ScheduleAction{
+			Action: &schedulepb.ScheduleAction_StartWorkflow{
+				StartWorkflow: &workflowpb.NewWorkflowExecutionInfo{
+					WorkflowId:               s.Action.WorkflowID,
+					WorkflowType:             &commonpb.WorkflowType{Name: s.Action.WorkflowType},
+					TaskQueue:                &taskqueuepb.TaskQueue{Name: s.Action.TaskQueue, Kind: enumspb.TASK_QUEUE_KIND_NORMAL},
+					Input:                    input,
+					WorkflowExecutionTimeout: durationPtr(s.Action.WorkflowExecutionTimeout),
+					WorkflowRunTimeout:       durationPtr(s.Action.WorkflowRunTimeout),
+					WorkflowTaskTimeout:      durationPtr(s.Action.WorkflowTaskTimeout),
+				},
+			},
+		},
+		Policies: &schedulepb.SchedulePolicies{
+			OverlapPolicy:  policy,
+			CatchupWindow:  durationPtr(s.Policies.CatchupWindow),
+			PauseOnFailure: s.Policies.PauseOnFailure,
+		},
+		State: &schedulepb.ScheduleState{
+			Notes:            s.State.Notes,
+			Paused:           s.State.Paused,
+			LimitedActions:   s.State.LimitedActions,
+			RemainingActions: s.State.RemainingActions,
+		},
+	}, nil
+}
+
+func scheduleFromProto(s *schedulepb.Schedule, dc converter.DataConverter) (*Schedule, error) {
+	out := &Schedule{}
+	if s == nil {
+		return out, nil
+	}
+
+	if spec := s.GetSpec(); spec != nil {
+		out.Spec = ScheduleSpec{
+			Calendars:        calendarsFromProto(spec.GetCalendar()),
+			ExcludeCalendars: calendarsFromProto(spec.GetExcludeCalendar()),
+			StartTime:        spec.GetStartTime(),
+			EndTime:          spec.GetEndTime(),
+			Jitter:           durationValue(spec.GetJitter()),
+			TimezoneName:     spec.GetTimezoneName(),
+		}
+
+		for _, interval := range spec.GetInterval() {
+			out.Spec.Intervals = append(out.Spec.Intervals, ScheduleInterval{
+				Interval: durationValue(interval.GetInterval()),
+				Phase:    durationValue(interval.GetPhase()),
+			})
+		}
+	}
+
+	if wf := s.GetAction().GetStartWorkflow(); wf != nil {
+		out.Action = ScheduleAction{
+			WorkflowID:               wf.GetWorkflowId(),
+			WorkflowType:             wf.GetWorkflowType().GetName(),
+			TaskQueue:                wf.GetTaskQueue().GetName(),
+			WorkflowExecutionTimeout: durationValue(wf.GetWorkflowExecutionTimeout()),
+			WorkflowRunTimeout:       durationValue(wf.GetWorkflowRunTimeout()),
+			WorkflowTaskTimeout:      durationValue(wf.GetWorkflowTaskTimeout()),
+		}
+
+		for _, p := range wf.GetInput().GetPayloads() {
+			var arg interface{}
+			err := dc.FromPayload(p, &arg)
+			if err != nil {
+				return nil, err
+			}
+
+			out.Action.Args = append(out.Action.Args, arg)
+		}
+	}
+
+	if policies := s.GetPolicies(); policies != nil {
+		out.Policies = SchedulePolicies{
+			OverlapPolicy:  overlapPolicyName(policies.GetOverlapPolicy()),
+			CatchupWindow:  durationValue(policies.GetCatchupWindow()),
+			PauseOnFailure: policies.GetPauseOnFailure(),
+		}
+	}
+
+	if state := s.GetState(); state != nil {
+		out.State = ScheduleState{
+			Notes:            state.GetNotes(),
+			Paused:           state.GetPaused(),
+			LimitedActions:   state.GetLimitedActions(),
+			RemainingActions: state.GetRemainingActions(),
+		}
+	}
+
+	return out, nil
+}
+
+// scheduleListEntryFromProto decodes the memo with the plugin data converter, search attributes are encoded by the
+// server with the default one.
+func scheduleListEntryFromProto(s *schedulepb.ScheduleListEntry, dc converter.DataConverter) (*ScheduleListEntry, error) {
+	memo, err := payloadsFromProto(s.GetMemo().GetFields(), dc)
+	if err != nil {
+		return nil, err
+	}
+
+	sa, err := payloadsFromProto(s.GetSearchAttributes().GetIndexedFields(), converter.GetDefaultDataConverter())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScheduleListEntry{
+		ScheduleID:       s.GetScheduleId(),
+		Memo:             memo,
+		SearchAttributes: sa,
+	}, nil
+}
+
+func payloadsFromProto(fields map[string]*commonpb.Payload, dc converter.DataConverter) (map[string]interface{}, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	out := make(map[string]interface{}, len(fields))
+	for k, p := range fields {
+		var v interface{}
+		err := dc.FromPayload(p, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+func scheduleInfoFromProto(info *schedulepb.ScheduleInfo) *ScheduleInfo {
+	out := &ScheduleInfo{}
+	if info == nil {
+		return out
+	}
+
+	out.ActionCount = info.GetActionCount()
+	out.MissedCatchupWindow = info.GetMissedCatchupWindow()
+	out.OverlapSkipped = info.GetOverlapSkipped()
+	out.FutureActionTimes = timesFromProto(info.GetFutureActionTimes())
+	out.CreateTime = info.GetCreateTime()
+	out.UpdateTime = info.GetUpdateTime()
+	out.InvalidScheduleError = info.GetInvalidScheduleError()
+
+	for _, we := range info.GetRunningWorkflows() {
+		out.RunningWorkflows = append(out.RunningWorkflows, ScheduledWorkflow{WorkflowID: we.GetWorkflowId(), RunID: we.GetRunId()})
+	}
+
+	for _, action := range info.GetRecentActions() {
+		res := ScheduleActionResult{
+			ScheduleTime: action.GetScheduleTime(),
+			ActualTime:   action.GetActualTime(),
+		}
+
+		if we := action.GetStartWorkflowResult(); we != nil {
+			res.Workflow = &ScheduledWorkflow{WorkflowID: we.GetWorkflowId(), RunID: we.GetRunId()}
+		}
+
+		out.RecentActions = append(out.RecentActions, res)
+	}
+
+	return out
+}
+
+func calendarsToProto(calendars []ScheduleCalendar) []*schedulepb.CalendarSpec {
+	out := make([]*schedulepb.CalendarSpec, 0, len(calendars))
+	for i := 0; i < len(calendars); i++ {
+		out = append(out, &schedulepb.CalendarSpec{
+			Second:     calendars[i].Second,
+			Minute:     calendars[i].Minute,
+			Hour:       calendars[i].Hour,
+			DayOfMonth: calendars[i].DayOfMonth,
+			Month:      calendars[i].Month,
+			Year:       calendars[i].Year,
+			DayOfWeek:  calendars[i].DayOfWeek,
+		})
+	}
+
+	return out
+}
+
+func calendarsFromProto(calendars []*schedulepb.CalendarSpec) []ScheduleCalendar {
+	out := make([]ScheduleCalendar, 0, len(calendars))
+	for _, c := range calendars {
+		out = append(out, ScheduleCalendar{
+			Second:     c.GetSecond(),
+			Minute:     c.GetMinute(),
+			Hour:       c.GetHour(),
+			DayOfMonth: c.GetDayOfMonth(),
+			Month:      c.GetMonth(),
+			Year:       c.GetYear(),
+			DayOfWeek:  c.GetDayOfWeek(),
+		})
+	}
+
+	return out
+}
+
+func timesFromProto(times []*time.Time) []time.Time {
+	out := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		if t != nil {
+			out = append(out, *t)
+		}
+	}
+
+	return out
+}
+
+// durationPtr returns nil for the zero duration, so the server default is used.
+func durationPtr(d Duration) *time.Duration {
+	if d == 0 {
+		return nil
+	}
+
+	td := time.Duration(d)
+	return &td
+}
+
+func durationValue(d *time.Duration) Duration {
+	if d == nil {
+		return 0
+	}
+
+	return Duration(*d)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
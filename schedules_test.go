@@ -0,0 +1,96 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	schedulepb "go.temporal.io/api/schedule/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+func Test_ScheduleProto(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	in := &Schedule{
+		Spec: ScheduleSpec{
+			Calendars:        []ScheduleCalendar{{Minute: "*/5", Hour: "9-17", DayOfWeek: "MON-FRI"}},
+			Intervals:        []ScheduleInterval{{Interval: Duration(time.Hour), Phase: Duration(time.Minute)}},
+			ExcludeCalendars: []ScheduleCalendar{{Month: "12", DayOfMonth: "25"}},
+			StartTime:        &start,
+			Jitter:           Duration(time.Second * 30),
+			TimezoneName:     "Europe/Berlin",
+		},
+		Action: ScheduleAction{
+			WorkflowID:          "wf-id",
+			WorkflowType:        "wf",
+			TaskQueue:           "default",
+			Args:                []interface{}{"arg", float64(1)},
+			WorkflowRunTimeout:  Duration(time.Hour),
+			WorkflowTaskTimeout: Duration(time.Second * 10),
+		},
+		Policies: SchedulePolicies{
+			OverlapPolicy:  "buffer_one",
+			CatchupWindow:  Duration(time.Minute * 10),
+			PauseOnFailure: true,
+		},
+		State: ScheduleState{
+			Notes:            "notes",
+			Paused:           true,
+			LimitedActions:   true,
+			RemainingActions: 10,
+		},
+	}
+
+	pb, err := scheduleToProto(in, converter.GetDefaultDataConverter())
+	require.NoError(t, err)
+	// zero durations use the server defaults
+	assert.Nil(t, pb.GetAction().GetStartWorkflow().GetWorkflowExecutionTimeout())
+
+	out, err := scheduleFromProto(pb, converter.GetDefaultDataConverter())
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func Test_ScheduleUnknownOverlapPolicy(t *testing.T) {
+	_, err := scheduleToProto(&Schedule{Policies: SchedulePolicies{OverlapPolicy: "unknown"}}, converter.GetDefaultDataConverter())
+	require.Error(t, err)
+}
+
+func Test_ScheduleDuration(t *testing.T) {
+	interval := ScheduleInterval{}
+	require.NoError(t, json.Unmarshal([]byte(`{"interval":"1h30m","phase":60000000000}`), &interval))
+	assert.Equal(t, ScheduleInterval{Interval: Duration(time.Minute * 90), Phase: Duration(time.Minute)}, interval)
+
+	require.Error(t, json.Unmarshal([]byte(`{"interval":"1 hour"}`), &interval))
+
+	data, err := json.Marshal(interval)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"interval":5400000000000,"phase":60000000000}`, string(data))
+}
+
+func Test_ScheduleListEntry(t *testing.T) {
+	dc := converter.GetDefaultDataConverter()
+	memo, err := dc.ToPayload("value")
+	require.NoError(t, err)
+	sa, err := dc.ToPayload([]string{"a", "b"})
+	require.NoError(t, err)
+
+	entry, err := scheduleListEntryFromProto(&schedulepb.ScheduleListEntry{
+		ScheduleId:       "id",
+		Memo:             &commonpb.Memo{Fields: map[string]*commonpb.Payload{"key": memo}},
+		SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{"CustomKeywordField": sa}},
+	}, dc)
+	require.NoError(t, err)
+	assert.Equal(t, &ScheduleListEntry{
+		ScheduleID:       "id",
+		Memo:             map[string]interface{}{"key": "value"},
+		SearchAttributes: map[string]interface{}{"CustomKeywordField": []interface{}{"a", "b"}},
+	}, entry)
+
+	entry, err = scheduleListEntryFromProto(&schedulepb.ScheduleListEntry{ScheduleId: "id"}, dc)
+	require.NoError(t, err)
+	assert.Equal(t, &ScheduleListEntry{ScheduleID: "id"}, entry)
+}
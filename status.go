@@ -0,0 +1,137 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/roadrunner-server/api/v2/plugins/status"
+	"github.com/roadrunner-server/api/v2/worker"
+	"github.com/temporalio/roadrunner-temporal/aggregatedpool"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+const (
+	healthCheckTimeout  = time.Second * 3
+	healthCheckInterval = time.Second * 10
+)
+
+// Status returns 200 when the temporal server is reachable and both pools have at least one active worker.
+func (p *Plugin) Status() (*status.Status, error) {
+	if atomic.LoadUint32(&p.resetting) == 1 {
+		return &status.Status{Code: http.StatusServiceUnavailable}, nil
+	}
+
+	// the health check takes up to the health check timeout, so it runs outside the lock
+	p.mu.RLock()
+	client := p.client
+	active := p.poolsActive()
+	p.mu.RUnlock()
+
+	if !active || !p.healthy(client) {
+		return &status.Status{Code: http.StatusServiceUnavailable}, nil
+	}
+
+	return &status.Status{Code: http.StatusOK}, nil
+}
+
+// Ready returns 200 when the plugin is healthy and the temporal workers are started (pollers are running).
+// Reset in progress is reported as not ready.
+func (p *Plugin) Ready() (*status.Status, error) {
+	if atomic.LoadUint32(&p.resetting) == 1 || atomic.LoadUint32(&p.started) == 0 {
+		return &status.Status{Code: http.StatusServiceUnavailable}, nil
+	}
+
+	return p.Status()
+}
+
+// healthy checks the temporal server connectivity with the client.
+func (p *Plugin) healthy(client temporalClient.Client) bool {
+	if client == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	_, err := client.CheckHealth(ctx, &temporalClient.CheckHealthRequest{})
+	if err != nil {
+		p.log.Warn("temporal server health check", zap.Error(err))
+		if atomic.CompareAndSwapUint32(&p.connected, 1, 0) {
+			p.lifecycle.Send(aggregatedpool.EventConnectionLost, err.Error())
+		}
+
+		return false
+	}
+
+	if atomic.CompareAndSwapUint32(&p.connected, 0, 1) {
+		p.lifecycle.Send(aggregatedpool.EventConnectionRestored, p.config.Address)
+	}
+
+	return true
+}
+
+// watchConnection checks the temporal server connectivity periodically, so the connection loss is reported even when
+// the status plugin is not used.
+func (p *Plugin) watchConnection() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.mu.RLock()
+			client := p.client
+			p.mu.RUnlock()
+
+			p.healthy(client)
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// watchClusters switches the RPC client to the first healthy cluster periodically.
+func (p *Plugin) watchClusters() {
+	if len(p.clusters) < 2 {
+		return
+	}
+
+	ticker := time.NewTicker(p.config.FailoverInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.failover()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// poolsActive checks that the workflow, activity and dedicated local activity pools have at least one ready or working
+// worker, should be called under the read lock.
+func (p *Plugin) poolsActive() bool {
+	if p.wfP == nil || p.actP == nil {
+		return false
+	}
+
+	if p.laP != nil && !hasActiveWorker(p.laP.Workers()) {
+		return false
+	}
+
+	return hasActiveWorker(p.wfP.Workers()) && hasActiveWorker(p.actP.Workers())
+}
+
+func hasActiveWorker(workers []worker.BaseProcess) bool {
+	for i := 0; i < len(workers); i++ {
+		if workers[i].State().IsActive() {
+			return true
+		}
+	}
+
+	return false
+}
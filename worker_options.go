@@ -0,0 +1,157 @@
+package roadrunner_temporal //nolint:revive,stylecheck
+
+import (
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/internal"
+	"go.temporal.io/sdk/worker"
+	"go.uber.org/zap"
+)
+
+const (
+	// WorkerOptionsMerge - configured options are used only when the PHP worker does not declare them
+	WorkerOptionsMerge string = "merge"
+	// WorkerOptionsOverride - configured options replace the PHP declared ones
+	WorkerOptionsOverride string = "override"
+
+	// WorkflowPanicPolicyBlock - the failed workflow task is retried until the workflow code is fixed
+	WorkflowPanicPolicyBlock string = "block"
+	// WorkflowPanicPolicyFail - the workflow fails on the PHP panic or deterministic error, transient errors are retried
+	WorkflowPanicPolicyFail string = "fail"
+)
+
+// WorkerOptions of the task queue, zero values are not applied.
+type WorkerOptions struct {
+	// Namespace of the task queue, the default namespace when empty
+	Namespace string `mapstructure:"namespace" json:"namespace"`
+	TaskQueue string `mapstructure:"task_queue" json:"taskQueue"`
+	// Mode is one of: merge (default), override
+	Mode string `mapstructure:"mode" json:"-"`
+
+	MaxConcurrentActivityExecutionSize      int           `mapstructure:"max_concurrent_activity_execution_size" json:"maxConcurrentActivityExecutionSize"`
+	WorkerActivitiesPerSecond               float64       `mapstructure:"worker_activities_per_second" json:"workerActivitiesPerSecond"`
+	MaxConcurrentLocalActivityExecutionSize int           `mapstructure:"max_concurrent_local_activity_execution_size" json:"maxConcurrentLocalActivityExecutionSize"`
+	WorkerLocalActivitiesPerSecond          float64       `mapstructure:"worker_local_activities_per_second" json:"workerLocalActivitiesPerSecond"`
+	TaskQueueActivitiesPerSecond            float64       `mapstructure:"task_queue_activities_per_second" json:"taskQueueActivitiesPerSecond"`
+	MaxConcurrentActivityTaskPollers        int           `mapstructure:"max_concurrent_activity_task_pollers" json:"maxConcurrentActivityTaskPollers"`
+	MaxConcurrentWorkflowTaskExecutionSize  int           `mapstructure:"max_concurrent_workflow_task_execution_size" json:"maxConcurrentWorkflowTaskExecutionSize"`
+	MaxConcurrentWorkflowTaskPollers        int           `mapstructure:"max_concurrent_workflow_task_pollers" json:"maxConcurrentWorkflowTaskPollers"`
+	StickyScheduleToStartTimeout            time.Duration `mapstructure:"sticky_schedule_to_start_timeout" json:"stickyScheduleToStartTimeout"`
+	// DeadlockDetectionTimeout bounds the workflow worker calls of the workflow task, 1s by the SDK default
+	DeadlockDetectionTimeout time.Duration `mapstructure:"deadlock_detection_timeout" json:"deadlockDetectionTimeout"`
+	// WorkflowPanicPolicy is one of: block, fail
+	WorkflowPanicPolicy string `mapstructure:"workflow_panic_policy" json:"workflowPanicPolicy"`
+}
+
+// applyWorkerOptions applies the workers configuration to the PHP declared worker options and logs the effective ones.
+// Should be called after namespaces are bound.
+func (p *Plugin) applyWorkerOptions(wi []*internal.WorkerInfo) {
+	for i := 0; i < len(wi); i++ {
+		namespace := p.namespaceName(wi[i].Namespace)
+		taskQueue := wi[i].TaskQueue
+		if taskQueue == "" {
+			taskQueue = "default"
+		}
+
+		for j := 0; j < len(p.config.Workers); j++ {
+			if p.config.Workers[j].TaskQueue != taskQueue || p.namespaceName(p.config.Workers[j].Namespace) != namespace {
+				continue
+			}
+
+			p.config.Workers[j].apply(&wi[i].Options)
+		}
+
+		p.log.Info("worker options", zap.String("namespace", namespace), zap.String("taskqueue", taskQueue), zap.Any("options", effectiveWorkerOptions(namespace, taskQueue, &wi[i].Options)))
+	}
+}
+
+func validateWorkerOptions(workers []*WorkerOptions) error {
+	for i := 0; i < len(workers); i++ {
+		switch workers[i].Mode {
+		case WorkerOptionsMerge, WorkerOptionsOverride:
+		default:
+			return errors.Errorf("task queue: %s, unknown worker options mode: %s, should be one of: %s, %s", workers[i].TaskQueue, workers[i].Mode, WorkerOptionsMerge, WorkerOptionsOverride)
+		}
+
+		switch workers[i].WorkflowPanicPolicy {
+		case "", WorkflowPanicPolicyBlock, WorkflowPanicPolicyFail:
+		default:
+			return errors.Errorf("task queue: %s, unknown workflow panic policy: %s, should be one of: %s, %s", workers[i].TaskQueue, workers[i].WorkflowPanicPolicy, WorkflowPanicPolicyBlock, WorkflowPanicPolicyFail)
+		}
+	}
+
+	return nil
+}
+
+func (wo *WorkerOptions) apply(opts *worker.Options) {
+	override := wo.Mode == WorkerOptionsOverride
+
+	setInt(&opts.MaxConcurrentActivityExecutionSize, wo.MaxConcurrentActivityExecutionSize, override)
+	setFloat(&opts.WorkerActivitiesPerSecond, wo.WorkerActivitiesPerSecond, override)
+	setInt(&opts.MaxConcurrentLocalActivityExecutionSize, wo.MaxConcurrentLocalActivityExecutionSize, override)
+	setFloat(&opts.WorkerLocalActivitiesPerSecond, wo.WorkerLocalActivitiesPerSecond, override)
+	setFloat(&opts.TaskQueueActivitiesPerSecond, wo.TaskQueueActivitiesPerSecond, override)
+	setInt(&opts.MaxConcurrentActivityTaskPollers, wo.MaxConcurrentActivityTaskPollers, override)
+	setInt(&opts.MaxConcurrentWorkflowTaskExecutionSize, wo.MaxConcurrentWorkflowTaskExecutionSize, override)
+	setInt(&opts.MaxConcurrentWorkflowTaskPollers, wo.MaxConcurrentWorkflowTaskPollers, override)
+
+	if wo.StickyScheduleToStartTimeout != 0 && (override || opts.StickyScheduleToStartTimeout == 0) {
+		opts.StickyScheduleToStartTimeout = wo.StickyScheduleToStartTimeout
+	}
+
+	if wo.DeadlockDetectionTimeout != 0 && (override || opts.DeadlockDetectionTimeout == 0) {
+		opts.DeadlockDetectionTimeout = wo.DeadlockDetectionTimeout
+	}
+
+	// block is the zero value, so it only matters in the override mode
+	switch wo.WorkflowPanicPolicy {
+	case WorkflowPanicPolicyFail:
+		if override || opts.WorkflowPanicPolicy == worker.BlockWorkflow {
+			opts.WorkflowPanicPolicy = worker.FailWorkflow
+		}
+	case WorkflowPanicPolicyBlock:
+		if override {
+			opts.WorkflowPanicPolicy = worker.BlockWorkflow
+		}
+	}
+}
+
+func setInt(dst *int, val int, override bool) {
+	if val != 0 && (override || *dst == 0) {
+		*dst = val
+	}
+}
+
+func setFloat(dst *float64, val float64, override bool) {
+	if val != 0 && (override || *dst == 0) {
+		*dst = val
+	}
+}
+
+// effectiveWorkerOptions returns options the worker is started with, zero values mean the SDK defaults.
+func effectiveWorkerOptions(namespace, taskQueue string, opts *worker.Options) *WorkerOptions {
+	return &WorkerOptions{
+		Namespace:                               namespace,
+		TaskQueue:                               taskQueue,
+		MaxConcurrentActivityExecutionSize:      opts.MaxConcurrentActivityExecutionSize,
+		WorkerActivitiesPerSecond:               opts.WorkerActivitiesPerSecond,
+		MaxConcurrentLocalActivityExecutionSize: opts.MaxConcurrentLocalActivityExecutionSize,
+		WorkerLocalActivitiesPerSecond:          opts.WorkerLocalActivitiesPerSecond,
+		TaskQueueActivitiesPerSecond:            opts.TaskQueueActivitiesPerSecond,
+		MaxConcurrentActivityTaskPollers:        opts.MaxConcurrentActivityTaskPollers,
+		MaxConcurrentWorkflowTaskExecutionSize:  opts.MaxConcurrentWorkflowTaskExecutionSize,
+		MaxConcurrentWorkflowTaskPollers:        opts.MaxConcurrentWorkflowTaskPollers,
+		StickyScheduleToStartTimeout:            opts.StickyScheduleToStartTimeout,
+		DeadlockDetectionTimeout:                opts.DeadlockDetectionTimeout,
+		WorkflowPanicPolicy:                     panicPolicyName(opts.WorkflowPanicPolicy),
+	}
+}
+
+func panicPolicyName(policy worker.WorkflowPanicPolicy) string {
+	if policy == worker.FailWorkflow {
+		return WorkflowPanicPolicyFail
+	}
+
+	return WorkflowPanicPolicyBlock
+}